@@ -0,0 +1,398 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package bindings
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// IProverVaultMetaData contains all meta data concerning the IProverVault contract.
+var IProverVaultMetaData = &bind.MetaData{
+	ABI: "[{\"type\":\"function\",\"name\":\"asset\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\"}]},{\"type\":\"function\",\"name\":\"decimals\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint8\"}]},{\"type\":\"function\",\"name\":\"totalSupply\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]},{\"type\":\"function\",\"name\":\"totalAssets\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]},{\"type\":\"function\",\"name\":\"balanceOf\",\"stateMutability\":\"view\",\"inputs\":[{\"name\":\"account\",\"type\":\"address\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]},{\"type\":\"function\",\"name\":\"convertToAssets\",\"stateMutability\":\"view\",\"inputs\":[{\"name\":\"shares\",\"type\":\"uint256\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]},{\"type\":\"function\",\"name\":\"convertToShares\",\"stateMutability\":\"view\",\"inputs\":[{\"name\":\"assets\",\"type\":\"uint256\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]}]",
+}
+
+// IProverVaultABI is the input ABI used to generate the binding from.
+// Deprecated: Use IProverVaultMetaData.ABI instead.
+var IProverVaultABI = IProverVaultMetaData.ABI
+
+// IProverVault is an auto generated Go binding around an Ethereum contract.
+type IProverVault struct {
+	IProverVaultCaller     // Read-only binding to the contract
+	IProverVaultTransactor // Write-only binding to the contract
+	IProverVaultFilterer   // Log filterer for contract events
+}
+
+// IProverVaultCaller is an auto generated read-only Go binding around an Ethereum contract.
+type IProverVaultCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IProverVaultTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type IProverVaultTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IProverVaultFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type IProverVaultFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IProverVaultSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type IProverVaultSession struct {
+	Contract     *IProverVault     // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// IProverVaultCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type IProverVaultCallerSession struct {
+	Contract *IProverVaultCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts       // Call options to use throughout this session
+}
+
+// IProverVaultTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type IProverVaultTransactorSession struct {
+	Contract     *IProverVaultTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts       // Transaction auth options to use throughout this session
+}
+
+// IProverVaultRaw is an auto generated low-level Go binding around an Ethereum contract.
+type IProverVaultRaw struct {
+	Contract *IProverVault // Generic contract binding to access the raw methods on
+}
+
+// IProverVaultCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type IProverVaultCallerRaw struct {
+	Contract *IProverVaultCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// IProverVaultTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type IProverVaultTransactorRaw struct {
+	Contract *IProverVaultTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewIProverVault creates a new instance of IProverVault, bound to a specific deployed contract.
+func NewIProverVault(address common.Address, backend bind.ContractBackend) (*IProverVault, error) {
+	contract, err := bindIProverVault(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &IProverVault{IProverVaultCaller: IProverVaultCaller{contract: contract}, IProverVaultTransactor: IProverVaultTransactor{contract: contract}, IProverVaultFilterer: IProverVaultFilterer{contract: contract}}, nil
+}
+
+// NewIProverVaultCaller creates a new read-only instance of IProverVault, bound to a specific deployed contract.
+func NewIProverVaultCaller(address common.Address, caller bind.ContractCaller) (*IProverVaultCaller, error) {
+	contract, err := bindIProverVault(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IProverVaultCaller{contract: contract}, nil
+}
+
+// NewIProverVaultTransactor creates a new write-only instance of IProverVault, bound to a specific deployed contract.
+func NewIProverVaultTransactor(address common.Address, transactor bind.ContractTransactor) (*IProverVaultTransactor, error) {
+	contract, err := bindIProverVault(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IProverVaultTransactor{contract: contract}, nil
+}
+
+// NewIProverVaultFilterer creates a new log filterer instance of IProverVault, bound to a specific deployed contract.
+func NewIProverVaultFilterer(address common.Address, filterer bind.ContractFilterer) (*IProverVaultFilterer, error) {
+	contract, err := bindIProverVault(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &IProverVaultFilterer{contract: contract}, nil
+}
+
+// bindIProverVault binds a generic wrapper to an already deployed contract.
+func bindIProverVault(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := IProverVaultMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IProverVault *IProverVaultRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IProverVault.Contract.IProverVaultCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IProverVault *IProverVaultRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IProverVault.Contract.IProverVaultTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IProverVault *IProverVaultRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IProverVault.Contract.IProverVaultTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IProverVault *IProverVaultCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IProverVault.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IProverVault *IProverVaultTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IProverVault.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IProverVault *IProverVaultTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IProverVault.Contract.contract.Transact(opts, method, params...)
+}
+
+// Asset is a free data retrieval call binding the contract method 0x38d52e0f.
+//
+// Solidity: function asset() view returns(address)
+func (_IProverVault *IProverVaultCaller) Asset(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "asset")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Asset is a free data retrieval call binding the contract method 0x38d52e0f.
+//
+// Solidity: function asset() view returns(address)
+func (_IProverVault *IProverVaultSession) Asset() (common.Address, error) {
+	return _IProverVault.Contract.Asset(&_IProverVault.CallOpts)
+}
+
+// Asset is a free data retrieval call binding the contract method 0x38d52e0f.
+//
+// Solidity: function asset() view returns(address)
+func (_IProverVault *IProverVaultCallerSession) Asset() (common.Address, error) {
+	return _IProverVault.Contract.Asset(&_IProverVault.CallOpts)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_IProverVault *IProverVaultCaller) BalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "balanceOf", account)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_IProverVault *IProverVaultSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _IProverVault.Contract.BalanceOf(&_IProverVault.CallOpts, account)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_IProverVault *IProverVaultCallerSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _IProverVault.Contract.BalanceOf(&_IProverVault.CallOpts, account)
+}
+
+// ConvertToAssets is a free data retrieval call binding the contract method 0x07a2d13a.
+//
+// Solidity: function convertToAssets(uint256 shares) view returns(uint256)
+func (_IProverVault *IProverVaultCaller) ConvertToAssets(opts *bind.CallOpts, shares *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "convertToAssets", shares)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ConvertToAssets is a free data retrieval call binding the contract method 0x07a2d13a.
+//
+// Solidity: function convertToAssets(uint256 shares) view returns(uint256)
+func (_IProverVault *IProverVaultSession) ConvertToAssets(shares *big.Int) (*big.Int, error) {
+	return _IProverVault.Contract.ConvertToAssets(&_IProverVault.CallOpts, shares)
+}
+
+// ConvertToAssets is a free data retrieval call binding the contract method 0x07a2d13a.
+//
+// Solidity: function convertToAssets(uint256 shares) view returns(uint256)
+func (_IProverVault *IProverVaultCallerSession) ConvertToAssets(shares *big.Int) (*big.Int, error) {
+	return _IProverVault.Contract.ConvertToAssets(&_IProverVault.CallOpts, shares)
+}
+
+// ConvertToShares is a free data retrieval call binding the contract method 0xc6e6f592.
+//
+// Solidity: function convertToShares(uint256 assets) view returns(uint256)
+func (_IProverVault *IProverVaultCaller) ConvertToShares(opts *bind.CallOpts, assets *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "convertToShares", assets)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ConvertToShares is a free data retrieval call binding the contract method 0xc6e6f592.
+//
+// Solidity: function convertToShares(uint256 assets) view returns(uint256)
+func (_IProverVault *IProverVaultSession) ConvertToShares(assets *big.Int) (*big.Int, error) {
+	return _IProverVault.Contract.ConvertToShares(&_IProverVault.CallOpts, assets)
+}
+
+// ConvertToShares is a free data retrieval call binding the contract method 0xc6e6f592.
+//
+// Solidity: function convertToShares(uint256 assets) view returns(uint256)
+func (_IProverVault *IProverVaultCallerSession) ConvertToShares(assets *big.Int) (*big.Int, error) {
+	return _IProverVault.Contract.ConvertToShares(&_IProverVault.CallOpts, assets)
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_IProverVault *IProverVaultCaller) Decimals(opts *bind.CallOpts) (uint8, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "decimals")
+
+	if err != nil {
+		return *new(uint8), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return out0, err
+
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_IProverVault *IProverVaultSession) Decimals() (uint8, error) {
+	return _IProverVault.Contract.Decimals(&_IProverVault.CallOpts)
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_IProverVault *IProverVaultCallerSession) Decimals() (uint8, error) {
+	return _IProverVault.Contract.Decimals(&_IProverVault.CallOpts)
+}
+
+// TotalAssets is a free data retrieval call binding the contract method 0x01e1d114.
+//
+// Solidity: function totalAssets() view returns(uint256)
+func (_IProverVault *IProverVaultCaller) TotalAssets(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "totalAssets")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// TotalAssets is a free data retrieval call binding the contract method 0x01e1d114.
+//
+// Solidity: function totalAssets() view returns(uint256)
+func (_IProverVault *IProverVaultSession) TotalAssets() (*big.Int, error) {
+	return _IProverVault.Contract.TotalAssets(&_IProverVault.CallOpts)
+}
+
+// TotalAssets is a free data retrieval call binding the contract method 0x01e1d114.
+//
+// Solidity: function totalAssets() view returns(uint256)
+func (_IProverVault *IProverVaultCallerSession) TotalAssets() (*big.Int, error) {
+	return _IProverVault.Contract.TotalAssets(&_IProverVault.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_IProverVault *IProverVaultCaller) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _IProverVault.contract.Call(opts, &out, "totalSupply")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_IProverVault *IProverVaultSession) TotalSupply() (*big.Int, error) {
+	return _IProverVault.Contract.TotalSupply(&_IProverVault.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_IProverVault *IProverVaultCallerSession) TotalSupply() (*big.Int, error) {
+	return _IProverVault.Contract.TotalSupply(&_IProverVault.CallOpts)
+}
@@ -0,0 +1,330 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagYes     = "yes"
+	FlagSafe    = "safe"
+	FlagConfirm = "confirm"
+)
+
+var (
+	adminYes     bool
+	adminSafe    string
+	adminConfirm string
+)
+
+func AdminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "owner operations on the market and staking contracts",
+	}
+	cmd.PersistentFlags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfirmFlags(cmd)
+	cmd.PersistentFlags().StringVar(&adminSafe, FlagSafe, "", "append the tx to this Safe Transaction Builder batch json instead of sending it")
+	cmd.AddCommand(AdminMarketCmd(), AdminStakingCmd())
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(AdminCmd())
+}
+
+// adminCall is a contract call that changes protocol settings or moves funds
+type adminCall struct {
+	Contract common.Address
+	ABI      string
+	Method   string
+	Args     []interface{}
+	Summary  string         // what the call does, printed before confirmation
+	Sender   common.Address // who is expected to send it, eg. the owner, and the safe of a --safe batch
+	// Destructive calls are confirmed by typing the method name instead of y
+	Destructive bool
+}
+
+// execAdminCall sends call after confirmation and checks the events it emitted,
+// or appends it to the --safe batch in which case the returned receipt is nil
+func execAdminCall(ec *ethclient.Client, c *ChainConfig, chid *big.Int, call *adminCall) (*types.Receipt, error) {
+	parsed, err := abi.JSON(strings.NewReader(call.ABI))
+	chkErr(err, "abi.JSON")
+	data, err := parsed.Pack(call.Method, call.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", call.Method, err)
+	}
+	fmt.Println(call.Summary)
+
+	var auth *bind.TransactOpts
+	var from common.Address
+	if adminSafe != "" {
+		// the safe sends it, so it is simulated from there
+		from = call.Sender
+	} else {
+		auth, from, err = CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+		chkErr(err, "CreateTransactOpts")
+	}
+	// dry run first so a missing permission or a revert shows before confirmation
+	_, err = ec.CallContract(context.Background(), ethereum.CallMsg{From: from, To: &call.Contract, Data: data}, nil)
+	if err != nil {
+		if errName := customErrorName(err, call.ABI); errName != "" {
+			err = fmt.Errorf("%w - %s", err, errName)
+		}
+		if adminSafe == "" && call.Sender != ZeroAddr && from != call.Sender {
+			return nil, fmt.Errorf("%s would fail: %w. signer %s is not %s, use --safe if that is a safe", call.Method, err, from.Hex(), call.Sender.Hex())
+		}
+		return nil, fmt.Errorf("%s would fail from %s: %w", call.Method, from.Hex(), err)
+	}
+
+	if adminSafe != "" {
+		err = appendSafeBatch(adminSafe, chid, call.Sender, call.Contract, data)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%s added to safe batch %s", call.Method, adminSafe)
+		return nil, nil
+	}
+
+	question := fmt.Sprintf("send %s to %s from %s?", call.Method, call.Contract.Hex(), from.Hex())
+	if call.Destructive {
+		if !confirmTyped(question, call.Method) {
+			return nil, errors.New("aborted")
+		}
+	} else if !confirm(question) {
+		return nil, errors.New("aborted")
+	}
+
+	contract := bind.NewBoundContract(call.Contract, parsed, ec, ec, ec)
+	tx, err := contract.Transact(auth, call.Method, call.Args...)
+	checkBrevisCustomError(err, call.Method, call.ABI)
+	log.Printf("%s tx: %s", call.Method, tx.Hash())
+	receipt, err := bind.WaitMined(context.Background(), ec, tx)
+	chkErr(err, "WaitMined")
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		log.Fatalf("%s tx status is not success", call.Method)
+	}
+	printReceiptEvents(&parsed, call.Contract, receipt)
+	return receipt, checkUpdatedEvent(&parsed, call, receipt)
+}
+
+// checkUpdatedEvent makes sure a setXxx call emitted XxxUpdated with the
+// values it set. Event fields named newXxx must equal the last call argument,
+// and fields named like a call argument must equal that argument. Calls with
+// no such event in the abi are not checked
+func checkUpdatedEvent(parsed *abi.ABI, call *adminCall, receipt *types.Receipt) error {
+	if !strings.HasPrefix(call.Method, "set") || len(call.Args) == 0 {
+		return nil
+	}
+	name := strings.ToUpper(call.Method[3:4]) + call.Method[4:] + "Updated"
+	ev, ok := parsed.Events[name]
+	if !ok {
+		return nil
+	}
+	method := parsed.Methods[call.Method]
+	want := make(map[string]interface{})
+	for _, arg := range ev.Inputs {
+		if strings.HasPrefix(arg.Name, "new") {
+			want[arg.Name] = call.Args[len(call.Args)-1]
+			continue
+		}
+		for i, in := range method.Inputs {
+			if in.Name == arg.Name {
+				want[arg.Name] = call.Args[i]
+			}
+		}
+	}
+	for _, l := range receipt.Logs {
+		if l.Address != call.Contract || len(l.Topics) == 0 || l.Topics[0] != ev.ID {
+			continue
+		}
+		_, fields, err := decodeEvent(parsed, l)
+		if err != nil {
+			return err
+		}
+		for field, v := range want {
+			if got := fmtAbiValue(fields[field]); got != fmtAbiValue(v) {
+				return fmt.Errorf("%s %s is %s, not the %s that was set", name, field, got, fmtAbiValue(v))
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s tx %s emitted no %s event", call.Method, receipt.TxHash.Hex(), name)
+}
+
+func addConfirmFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&adminYes, FlagYes, false, "send without asking for confirmation, except destructive calls")
+	cmd.PersistentFlags().StringVar(&adminConfirm, FlagConfirm, "", "send a destructive call without typing its method name, the value must be that name")
+}
+
+// confirm asks a yes/no question on stdin, --yes answers it
+func confirm(question string) bool {
+	if adminYes {
+		return true
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// confirmTyped asks to type word to go on, only --confirm=<word> answers it
+// so a generic --yes never sends a destructive call
+func confirmTyped(question, word string) bool {
+	if adminConfirm != "" {
+		if adminConfirm == word {
+			return true
+		}
+		fmt.Printf("--%s is %s but the call is %s\n", FlagConfirm, adminConfirm, word)
+		return false
+	}
+	fmt.Printf("%s type %s to confirm: ", question, word)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == word
+}
+
+// printReceiptEvents logs the events contract emitted in receipt, with their fields in abi order
+func printReceiptEvents(parsed *abi.ABI, contract common.Address, receipt *types.Receipt) {
+	n := 0
+	for _, l := range receipt.Logs {
+		if l.Address != contract || len(l.Topics) == 0 {
+			continue
+		}
+		ev, fields, err := decodeEvent(parsed, l)
+		if err != nil {
+			log.Printf("decode event: %s", err)
+			continue
+		}
+		if ev == nil {
+			continue
+		}
+		var args []string
+		for _, arg := range ev.Inputs {
+			args = append(args, fmt.Sprintf("%s=%s", arg.Name, fmtAbiValue(fields[arg.Name])))
+		}
+		log.Printf("event %s(%s)", ev.Name, strings.Join(args, ", "))
+		n++
+	}
+	if n == 0 {
+		log.Printf("no event emitted by %s, the value may have been unchanged", contract.Hex())
+	}
+}
+
+// decodeEvent unpacks the indexed and data fields of l by name, ev is nil if
+// the event is not in parsed
+func decodeEvent(parsed *abi.ABI, l *types.Log) (*abi.Event, map[string]interface{}, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil, nil
+	}
+	ev, err := parsed.EventByID(l.Topics[0])
+	if err != nil {
+		return nil, nil, nil
+	}
+	fields := make(map[string]interface{})
+	if len(ev.Inputs.NonIndexed()) > 0 {
+		if err = parsed.UnpackIntoMap(fields, ev.Name, l.Data); err != nil {
+			return nil, nil, fmt.Errorf("unpack %s: %w", ev.Name, err)
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range ev.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err = abi.ParseTopicsIntoMap(fields, indexed, l.Topics[1:]); err != nil {
+		return nil, nil, fmt.Errorf("parse %s topics: %w", ev.Name, err)
+	}
+	return ev, fields, nil
+}
+
+func fmtAbiValue(v interface{}) string {
+	switch v := v.(type) {
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case []byte:
+		return hexutil.Encode(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// safeBatch is the Safe Transaction Builder import format
+type safeBatch struct {
+	Version      string        `json:"version"`
+	ChainId      string        `json:"chainId"`
+	CreatedAt    int64         `json:"createdAt"`
+	Meta         safeBatchMeta `json:"meta"`
+	Transactions []safeTx      `json:"transactions"`
+}
+
+type safeBatchMeta struct {
+	Name                    string `json:"name"`
+	Description             string `json:"description"`
+	TxBuilderVersion        string `json:"txBuilderVersion"`
+	CreatedFromSafeAddress  string `json:"createdFromSafeAddress"`
+	CreatedFromOwnerAddress string `json:"createdFromOwnerAddress"`
+}
+
+type safeTx struct {
+	To                   string      `json:"to"`
+	Value                string      `json:"value"`
+	Data                 string      `json:"data"`
+	ContractMethod       interface{} `json:"contractMethod"`
+	ContractInputsValues interface{} `json:"contractInputsValues"`
+}
+
+// appendSafeBatch adds a tx to the batch file, creating it if it does not
+// exist, so several admin commands can be signed as one safe transaction
+func appendSafeBatch(file string, chid *big.Int, safe, to common.Address, data []byte) error {
+	batch := &safeBatch{
+		Version:   "1.0",
+		ChainId:   chid.String(),
+		CreatedAt: time.Now().UnixMilli(),
+		Meta: safeBatchMeta{
+			Name:                   "brevis admin batch",
+			TxBuilderVersion:       "1.16.5",
+			CreatedFromSafeAddress: safe.Hex(),
+		},
+	}
+	raw, err := os.ReadFile(file)
+	if err == nil {
+		if err = json.Unmarshal(raw, batch); err != nil {
+			return fmt.Errorf("parse safe batch %s: %w", file, err)
+		}
+		if batch.ChainId != chid.String() {
+			return fmt.Errorf("safe batch %s is for chain %s", file, batch.ChainId)
+		}
+		if !strings.EqualFold(batch.Meta.CreatedFromSafeAddress, safe.Hex()) {
+			return fmt.Errorf("safe batch %s is for safe %s but this call needs %s", file, batch.Meta.CreatedFromSafeAddress, safe.Hex())
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	batch.Transactions = append(batch.Transactions, safeTx{To: to.Hex(), Value: "0", Data: hexutil.Encode(data)})
+	raw, err = json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, raw, 0644)
+}
@@ -0,0 +1,454 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+// marketState is what the market params are shown and validated against
+type marketState struct {
+	*marketParams
+	brevisMarket   *bindings.BrevisMarket
+	Owner          common.Address
+	BpsDenominator *big.Int
+	OvercommitBps  *big.Int
+	ProtocolFeeBps *big.Int
+	SlashBps       *big.Int
+	SlashWindow    *big.Int
+	FeeToken       *token
+}
+
+// marketParam is a BrevisMarket setting the owner can change
+type marketParam struct {
+	Name   string
+	Args   string // set arguments
+	Help   string
+	Method string // setter
+	// Get returns the current value, args are the set arguments if any
+	Get func(s *marketState, args []string) (string, error)
+	// Parse validates the set arguments and returns the setter arguments
+	Parse func(s *marketState, args []string) ([]interface{}, error)
+}
+
+var marketParamList = []*marketParam{
+	{
+		Name: "bidding-phase-duration", Args: "<seconds>", Method: "setBiddingPhaseDuration",
+		Help: "seconds after a request during which provers can bid",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtSeconds(s.BiddingPhaseDuration), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			d, err := parsePhaseDuration(args[0], s.RevealPhaseDuration, s.MaxDeadlineDuration)
+			return []interface{}{d}, err
+		},
+	},
+	{
+		Name: "reveal-phase-duration", Args: "<seconds>", Method: "setRevealPhaseDuration",
+		Help: "seconds after the bidding phase during which provers reveal bids",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtSeconds(s.RevealPhaseDuration), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			d, err := parsePhaseDuration(args[0], s.BiddingPhaseDuration, s.MaxDeadlineDuration)
+			return []interface{}{d}, err
+		},
+	},
+	{
+		Name: "min-max-fee", Args: "<amount>", Method: "setMinMaxFee",
+		Help: "lowest maxFee a request can set, eg. \"0.1 BREV\" in fee token units or raw wei",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return s.FeeToken.fmt(s.MinMaxFee), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			fee, err := s.FeeToken.parse(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if s.MaxMaxFee.Sign() > 0 && fee.Cmp(s.MaxMaxFee) > 0 {
+				return nil, fmt.Errorf("min-max-fee %s is above max-max-fee %s", s.FeeToken.fmt(fee), s.FeeToken.fmt(s.MaxMaxFee))
+			}
+			return []interface{}{fee}, nil
+		},
+	},
+	{
+		Name: "max-max-fee", Args: "<amount>", Method: "setMaxMaxFee",
+		Help: "highest maxFee a request can set, 0 for no limit, eg. \"100 BREV\" in fee token units or raw wei",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return s.FeeToken.fmt(s.MaxMaxFee), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			fee, err := s.FeeToken.parse(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if fee.Sign() > 0 && fee.Cmp(s.MinMaxFee) < 0 {
+				return nil, fmt.Errorf("max-max-fee %s is below min-max-fee %s", s.FeeToken.fmt(fee), s.FeeToken.fmt(s.MinMaxFee))
+			}
+			return []interface{}{fee}, nil
+		},
+	},
+	{
+		Name: "overcommit-bps", Args: "<bps>", Method: "setOvercommitBps",
+		Help: "share of a prover's assets that can be assigned beyond its stake",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtBps(s.OvercommitBps), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			bps, err := parseBps(args[0], s.BpsDenominator)
+			return []interface{}{bps}, err
+		},
+	},
+	{
+		Name: "protocol-fee-bps", Args: "<bps>", Method: "setProtocolFeeBps",
+		Help: "share of each proof fee kept by the protocol",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtBps(s.ProtocolFeeBps), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			bps, err := parseBps(args[0], s.BpsDenominator)
+			return []interface{}{bps}, err
+		},
+	},
+	{
+		Name: "slash-bps", Args: "<bps>", Method: "setSlashBps",
+		Help: "share of the assigned stake slashed when a prover misses the deadline",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtBps(s.SlashBps), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			bps, err := parseBps(args[0], s.BpsDenominator)
+			return []interface{}{bps}, err
+		},
+	},
+	{
+		Name: "slash-window", Args: "<seconds>", Method: "setSlashWindow",
+		Help: "seconds after a missed deadline during which the prover can be slashed",
+		Get: func(s *marketState, _ []string) (string, error) {
+			return fmtSeconds(s.SlashWindow.Uint64()), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			window, err := parseUint256(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if window.Sign() == 0 {
+				return nil, fmt.Errorf("slash-window should be larger than 0")
+			}
+			return []interface{}{window}, nil
+		},
+	},
+	{
+		Name: "pico-verifier", Args: "<version> <address>", Method: "setPicoVerifier",
+		Help: "pico verifier contract for a proof version",
+		Get: func(s *marketState, args []string) (string, error) {
+			version := uint64(0)
+			if len(args) > 0 {
+				var err error
+				if version, err = strconv.ParseUint(args[0], 10, 32); err != nil {
+					return "", fmt.Errorf("invalid version %s", args[0])
+				}
+			}
+			verifier, err := s.brevisMarket.PicoVerifiers(nil, uint32(version))
+			if err != nil {
+				return "", fmt.Errorf("PicoVerifiers: %w", err)
+			}
+			return fmt.Sprintf("%s (version %d)", verifier.Hex(), version), nil
+		},
+		Parse: func(s *marketState, args []string) ([]interface{}, error) {
+			version, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version %s", args[0])
+			}
+			if !common.IsHexAddress(args[1]) {
+				return nil, fmt.Errorf("invalid verifier address %s", args[1])
+			}
+			return []interface{}{uint32(version), common.HexToAddress(args[1])}, nil
+		},
+	},
+}
+
+func findMarketParam(name string) (*marketParam, error) {
+	var names []string
+	for _, p := range marketParamList {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return nil, fmt.Errorf("unknown param %q, one of %s", name, strings.Join(names, ", "))
+}
+
+func AdminMarketCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "market",
+		Short: "show and change BrevisMarket parameters",
+	}
+	var names, help []string
+	for _, p := range marketParamList {
+		names = append(names, p.Name)
+		help = append(help, fmt.Sprintf("  %-24s %-20s %s", p.Name, p.Args, p.Help))
+	}
+	get := &cobra.Command{
+		Use:       "get [param] [version]",
+		Short:     "show all parameters or one of them",
+		Args:      cobra.MaximumNArgs(2),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminMarketGet(args)
+		},
+	}
+	set := &cobra.Command{
+		Use:       "set <param> <value>...",
+		Short:     "change a parameter",
+		Long:      "change a parameter, after showing the current value and asking for confirmation. Params:\n" + strings.Join(help, "\n"),
+		Args:      cobra.MinimumNArgs(2),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminMarketSet(args[0], args[1:])
+		},
+	}
+	withdraw := &cobra.Command{
+		Use:   "withdraw-protocol-fee <to>",
+		Short: "withdraw the accrued protocol fee",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminMarketWithdrawProtocolFee(args[0])
+		},
+	}
+	cmd.AddCommand(get, set, withdraw)
+	return cmd
+}
+
+// dialChain reads the chain config and dials the rpc, checking the chain id
+func dialChain() (*ChainConfig, *ethclient.Client, *big.Int, error) {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return nil, nil, nil, fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+	return &c, ec, chid, nil
+}
+
+// dialMarket is dialChain plus a BrevisMarket binding
+func dialMarket() (*ChainConfig, *ethclient.Client, *big.Int, *bindings.BrevisMarket, error) {
+	c, ec, chid, err := dialChain()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	return c, ec, chid, brevisMarket, nil
+}
+
+func getMarketState(ec *ethclient.Client, c *ChainConfig, brevisMarket *bindings.BrevisMarket) (*marketState, error) {
+	params, err := getMarketParams(brevisMarket)
+	if err != nil {
+		return nil, err
+	}
+	s := &marketState{marketParams: params, brevisMarket: brevisMarket}
+	if s.Owner, err = brevisMarket.Owner(nil); err != nil {
+		return nil, fmt.Errorf("Owner: %w", err)
+	}
+	if s.BpsDenominator, err = brevisMarket.BPSDENOMINATOR(nil); err != nil {
+		return nil, fmt.Errorf("BPSDENOMINATOR: %w", err)
+	}
+	if s.OvercommitBps, err = brevisMarket.OvercommitBps(nil); err != nil {
+		return nil, fmt.Errorf("OvercommitBps: %w", err)
+	}
+	if s.ProtocolFeeBps, err = brevisMarket.ProtocolFeeBps(nil); err != nil {
+		return nil, fmt.Errorf("ProtocolFeeBps: %w", err)
+	}
+	if s.SlashBps, err = brevisMarket.SlashBps(nil); err != nil {
+		return nil, fmt.Errorf("SlashBps: %w", err)
+	}
+	if s.SlashWindow, err = brevisMarket.SlashWindow(nil); err != nil {
+		return nil, fmt.Errorf("SlashWindow: %w", err)
+	}
+	if s.FeeToken, err = getFeeToken(ec, c); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func adminMarketGet(args []string) error {
+	c, ec, _, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	s, err := getMarketState(ec, c, brevisMarket)
+	chkErr(err, "getMarketState")
+
+	if len(args) > 0 {
+		p, err := findMarketParam(args[0])
+		if err != nil {
+			return err
+		}
+		v, err := p.Get(s, args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Println(v)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "owner\t%s\n", s.Owner.Hex())
+	fmt.Fprintf(w, "max-deadline-duration\t%s\n", fmtSeconds(s.MaxDeadlineDuration))
+	for _, p := range marketParamList {
+		v, err := p.Get(s, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, v)
+	}
+	w.Flush()
+	return nil
+}
+
+func adminMarketSet(name string, args []string) error {
+	p, err := findMarketParam(name)
+	if err != nil {
+		return err
+	}
+	if want := len(strings.Fields(p.Args)); len(args) != want {
+		return fmt.Errorf("%s takes %s", p.Name, p.Args)
+	}
+	c, ec, chid, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	s, err := getMarketState(ec, c, brevisMarket)
+	chkErr(err, "getMarketState")
+	values, err := p.Parse(s, args)
+	if err != nil {
+		return err
+	}
+	if p.Name == "pico-verifier" {
+		code, err := ec.CodeAt(context.Background(), values[1].(common.Address), nil)
+		chkErr(err, "CodeAt")
+		if len(code) == 0 {
+			return fmt.Errorf("verifier %s has no code", args[1])
+		}
+	}
+	current, err := p.Get(s, args)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := execAdminCall(ec, c, chid, &adminCall{
+		Contract: common.HexToAddress(c.BrevisMarketAddr),
+		ABI:      bindings.BrevisMarketABI,
+		Method:   p.Method,
+		Args:     values,
+		Summary:  fmt.Sprintf("%s: %s -> %s", p.Name, current, strings.Join(args, " ")),
+		Sender:   s.Owner,
+	})
+	if err != nil || receipt == nil {
+		return err
+	}
+	s, err = getMarketState(ec, c, brevisMarket)
+	chkErr(err, "getMarketState")
+	now, err := p.Get(s, args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s is now %s\n", p.Name, now)
+	return nil
+}
+
+func adminMarketWithdrawProtocolFee(to string) error {
+	if !common.IsHexAddress(to) {
+		return fmt.Errorf("invalid recipient address %s", to)
+	}
+	c, ec, chid, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	owner, err := brevisMarket.Owner(nil)
+	chkErr(err, "Owner")
+	feeInfo, err := brevisMarket.GetProtocolFeeInfo(nil)
+	chkErr(err, "GetProtocolFeeInfo")
+	if feeInfo.Balance.Sign() == 0 {
+		return fmt.Errorf("no protocol fee to withdraw")
+	}
+	feeToken, err := getFeeToken(ec, c)
+	chkErr(err, "getFeeToken")
+	_, err = execAdminCall(ec, c, chid, &adminCall{
+		Contract: common.HexToAddress(c.BrevisMarketAddr),
+		ABI:      bindings.BrevisMarketABI,
+		Method:   "withdrawProtocolFee",
+		Args:     []interface{}{common.HexToAddress(to)},
+		Summary:  fmt.Sprintf("withdraw protocol fee %s to %s", feeToken.fmt(feeInfo.Balance), common.HexToAddress(to).Hex()),
+		Sender:   owner,
+	})
+	return err
+}
+
+// parsePhaseDuration checks a bidding or reveal phase duration, both phases
+// have to end before the max deadline duration for requests to be possible
+func parsePhaseDuration(s string, otherPhase, maxDeadlineDuration uint64) (uint64, error) {
+	d, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s, should be seconds", s)
+	}
+	if d == 0 {
+		return 0, fmt.Errorf("duration should be larger than 0")
+	}
+	if d+otherPhase >= maxDeadlineDuration {
+		return 0, fmt.Errorf("bidding and reveal phases would take %s, not shorter than the max deadline duration %s",
+			fmtSeconds(d+otherPhase), fmtSeconds(maxDeadlineDuration))
+	}
+	return d, nil
+}
+
+func parseBps(s string, denominator *big.Int) (*big.Int, error) {
+	bps, ok := new(big.Int).SetString(s, 10)
+	if !ok || bps.Sign() < 0 {
+		return nil, fmt.Errorf("invalid bps %s", s)
+	}
+	if bps.Cmp(denominator) > 0 {
+		return nil, fmt.Errorf("bps %s is above %s", bps, denominator)
+	}
+	return bps, nil
+}
+
+func parseUint256(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok || v.Sign() < 0 || v.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid uint256 %s", s)
+	}
+	return v, nil
+}
+
+func fmtSeconds(s uint64) string {
+	return fmt.Sprintf("%d (%s)", s, time.Duration(s)*time.Second)
+}
+
+func fmtBps(bps *big.Int) string {
+	f, _ := new(big.Float).SetInt(bps).Float64()
+	return fmt.Sprintf("%s (%.2f%%)", bps, f/100)
+}
@@ -0,0 +1,557 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagBps    = "bps"
+	FlagAmount = "amount"
+)
+
+var (
+	slashBps    string
+	slashAmount string
+)
+
+// bpsDenominator is the basis point scale used by the staking controller
+var bpsDenominator = big.NewInt(10000)
+
+// stakingState is what the staking params are shown and validated against
+type stakingState struct {
+	ec                *ethclient.Client
+	c                 *ChainConfig
+	stakingController *bindings.IStakingController
+	Owner             common.Address
+	MinSelfStake      *big.Int
+	MaxSlashBps       *big.Int
+	UnstakeDelay      *big.Int
+	StakingToken      common.Address
+	Token             *token
+}
+
+// stakingParam is a StakingController setting the owner can change
+type stakingParam struct {
+	Name   string
+	Args   string
+	Help   string
+	Method string
+	// Get returns the current value, empty if the controller has no getter for it
+	Get   func(s *stakingState) string
+	Parse func(s *stakingState, arg string) (interface{}, error)
+	// Impact describes what the new value changes, printed before confirmation
+	Impact func(s *stakingState, v interface{}) (string, error)
+}
+
+var stakingParamList = []*stakingParam{
+	{
+		Name: "min-self-stake", Args: "<amount>", Method: "setMinSelfStake",
+		Help: "assets a prover must keep staked to itself, eg. \"1000 BREV\" in staking token units or raw wei",
+		Get: func(s *stakingState) string {
+			return s.Token.fmt(s.MinSelfStake)
+		},
+		Parse: func(s *stakingState, arg string) (interface{}, error) {
+			return s.Token.parse(arg)
+		},
+		Impact: minSelfStakeImpact,
+	},
+	{
+		Name: "max-slash-bps", Args: "<bps>", Method: "setMaxSlashBps",
+		Help: "largest share of a prover's assets a single slash can take",
+		Get: func(s *stakingState) string {
+			return fmtBps(s.MaxSlashBps)
+		},
+		Parse: func(s *stakingState, arg string) (interface{}, error) {
+			return parseBps(arg, bpsDenominator)
+		},
+		Impact: func(s *stakingState, v interface{}) (string, error) {
+			brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(s.c.BrevisMarketAddr), s.ec)
+			chkErr(err, "NewBrevisMarket")
+			marketSlashBps, err := brevisMarket.SlashBps(nil)
+			if err != nil {
+				return "", fmt.Errorf("SlashBps: %w", err)
+			}
+			if marketSlashBps.Cmp(v.(*big.Int)) > 0 {
+				return fmt.Sprintf("WARNING: market slash-bps %s is above it, market slashes would exceed the max", fmtBps(marketSlashBps)), nil
+			}
+			return fmt.Sprintf("market slash-bps %s is within it", fmtBps(marketSlashBps)), nil
+		},
+	},
+	{
+		Name: "unstake-delay", Args: "<seconds>", Method: "setUnstakeDelay",
+		Help: "seconds between an unstake request and its completion",
+		Get: func(s *stakingState) string {
+			return fmtSeconds(s.UnstakeDelay.Uint64())
+		},
+		Parse: func(s *stakingState, arg string) (interface{}, error) {
+			return parseUint256(arg)
+		},
+		Impact: func(s *stakingState, v interface{}) (string, error) {
+			provers, err := listProvers(s.stakingController)
+			if err != nil {
+				return "", err
+			}
+			total := big.NewInt(0)
+			for _, p := range provers {
+				n, err := s.stakingController.GetStakersWithPendingUnstakesCount(nil, p)
+				if err != nil {
+					return "", fmt.Errorf("GetStakersWithPendingUnstakesCount: %w", err)
+				}
+				total.Add(total, n)
+			}
+			return fmt.Sprintf("%s stakers have pending unstakes now", total), nil
+		},
+	},
+	{
+		Name: "require-authorization", Args: "<true|false>", Method: "setRequireAuthorization",
+		Help: "whether new provers need to be authorized before initializing",
+		Get: func(s *stakingState) string {
+			return ""
+		},
+		Parse: func(s *stakingState, arg string) (interface{}, error) {
+			return strconv.ParseBool(arg)
+		},
+	},
+}
+
+func findStakingParam(name string) (*stakingParam, error) {
+	var names []string
+	for _, p := range stakingParamList {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return nil, fmt.Errorf("unknown param %q, one of %s", name, strings.Join(names, ", "))
+}
+
+func AdminStakingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "staking",
+		Short: "show and change StakingController parameters, jail, slash and move treasury funds",
+	}
+	var names, help []string
+	for _, p := range stakingParamList {
+		names = append(names, p.Name)
+		help = append(help, fmt.Sprintf("  %-24s %-20s %s", p.Name, p.Args, p.Help))
+	}
+	get := &cobra.Command{
+		Use:   "get",
+		Short: "show parameters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingGet()
+		},
+	}
+	set := &cobra.Command{
+		Use:       "set <param> <value>",
+		Short:     "change a parameter",
+		Long:      "change a parameter, after showing what it changes and asking for confirmation. Params:\n" + strings.Join(help, "\n"),
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingSet(args[0], args[1])
+		},
+	}
+	jail := &cobra.Command{
+		Use:   "jail <prover>...",
+		Short: "jail provers",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingJail(args)
+		},
+	}
+	slash := &cobra.Command{
+		Use:   "slash <prover>",
+		Short: "slash a prover by bps of its assets or by amount",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingSlash(args[0])
+		},
+	}
+	slash.Flags().StringVar(&slashBps, FlagBps, "", "share of the prover's assets to slash in bps")
+	slash.Flags().StringVar(&slashAmount, FlagAmount, "", "amount to slash, eg. \"100 BREV\" in staking token units or raw wei")
+	addRewards := &cobra.Command{
+		Use:   "add-rewards <prover> <amount>",
+		Short: "add staking token rewards to a prover, split between its commission and stakers",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingAddRewards(args[0], args[1])
+		},
+	}
+	withdrawTreasury := &cobra.Command{
+		Use:   "withdraw-treasury <to> <amount>",
+		Short: "withdraw from the staking controller treasury",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingWithdraw("withdrawTreasury", args[0], args[1])
+		},
+	}
+	emergencyRecover := &cobra.Command{
+		Use:   "emergency-recover <to> <amount>",
+		Short: "move staking tokens out of the staking controller, including staked ones",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return adminStakingWithdraw("emergencyRecover", args[0], args[1])
+		},
+	}
+	cmd.AddCommand(get, set, jail, slash, addRewards, withdrawTreasury, emergencyRecover)
+	return cmd
+}
+
+func getStakingState() (*stakingState, *big.Int, error) {
+	c, ec, chid, err := dialChain()
+	if err != nil {
+		return nil, nil, err
+	}
+	addr := common.HexToAddress(c.StakingControllerAddr)
+	stakingController, err := bindings.NewIStakingController(addr, ec)
+	chkErr(err, "NewIStakingController")
+	ownable, err := bindings.NewOwnable(addr, ec)
+	chkErr(err, "NewOwnable")
+
+	s := &stakingState{ec: ec, c: c, stakingController: stakingController}
+	if s.Owner, err = ownable.Owner(nil); err != nil {
+		return nil, nil, fmt.Errorf("Owner: %w", err)
+	}
+	if s.MinSelfStake, err = stakingController.MinSelfStake(nil); err != nil {
+		return nil, nil, fmt.Errorf("MinSelfStake: %w", err)
+	}
+	if s.MaxSlashBps, err = stakingController.MaxSlashBps(nil); err != nil {
+		return nil, nil, fmt.Errorf("MaxSlashBps: %w", err)
+	}
+	if s.UnstakeDelay, err = stakingController.UnstakeDelay(nil); err != nil {
+		return nil, nil, fmt.Errorf("UnstakeDelay: %w", err)
+	}
+	if s.StakingToken, err = stakingController.StakingToken(nil); err != nil {
+		return nil, nil, fmt.Errorf("StakingToken: %w", err)
+	}
+	if s.Token, err = getToken(ec, s.StakingToken); err != nil {
+		return nil, nil, err
+	}
+	return s, chid, nil
+}
+
+// call returns an adminCall to the staking controller expected from the owner
+func (s *stakingState) call(method string, args ...interface{}) *adminCall {
+	return &adminCall{
+		Contract: common.HexToAddress(s.c.StakingControllerAddr),
+		ABI:      bindings.IStakingControllerABI,
+		Method:   method,
+		Args:     args,
+		Sender:   s.Owner,
+	}
+}
+
+func adminStakingGet() error {
+	s, _, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "owner\t%s\n", s.Owner.Hex())
+	fmt.Fprintf(w, "staking-token\t%s (%s, %d decimals)\n", s.StakingToken.Hex(), s.Token.Symbol, s.Token.Decimals)
+	for _, p := range stakingParamList {
+		if v := p.Get(s); v != "" {
+			fmt.Fprintf(w, "%s\t%s\n", p.Name, v)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+func adminStakingSet(name, arg string) error {
+	p, err := findStakingParam(name)
+	if err != nil {
+		return err
+	}
+	s, chid, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	v, err := p.Parse(s, arg)
+	if err != nil {
+		return err
+	}
+	call := s.call(p.Method, v)
+	call.Summary = fmt.Sprintf("%s: %s -> %s", p.Name, p.Get(s), arg)
+	if p.Get(s) == "" {
+		call.Summary = fmt.Sprintf("%s: -> %s", p.Name, arg)
+	}
+	if p.Impact != nil {
+		impact, err := p.Impact(s, v)
+		if err != nil {
+			return err
+		}
+		call.Summary += "\n" + impact
+	}
+	receipt, err := execAdminCall(s.ec, s.c, chid, call)
+	if err != nil || receipt == nil {
+		return err
+	}
+	s, _, err = getStakingState()
+	chkErr(err, "getStakingState")
+	if now := p.Get(s); now != "" {
+		fmt.Printf("%s is now %s\n", p.Name, now)
+	}
+	return nil
+}
+
+// minSelfStakeImpact lists the active provers whose self stake would be below min
+func minSelfStakeImpact(s *stakingState, v interface{}) (string, error) {
+	min := v.(*big.Int)
+	count, err := s.stakingController.GetProverCount(nil, true)
+	if err != nil {
+		return "", fmt.Errorf("GetProverCount: %w", err)
+	}
+	if count.Sign() == 0 {
+		return "no active provers", nil
+	}
+	provers, err := s.stakingController.GetProvers(nil, true, big.NewInt(0), count)
+	if err != nil {
+		return "", fmt.Errorf("GetProvers: %w", err)
+	}
+	var below []string
+	for _, p := range provers {
+		selfStake, err := proverSelfStake(s, p)
+		if err != nil {
+			return "", err
+		}
+		if selfStake.Cmp(min) < 0 {
+			below = append(below, fmt.Sprintf("  %s self stake %s", p.Hex(), s.Token.fmt(selfStake)))
+		}
+	}
+	summary := fmt.Sprintf("%d of %d active provers would be below it", len(below), len(provers))
+	if len(below) > 0 {
+		summary += ":\n" + strings.Join(below, "\n")
+	}
+	return summary, nil
+}
+
+// proverSelfStake is the value of the prover's shares in its own vault
+func proverSelfStake(s *stakingState, prover common.Address) (*big.Int, error) {
+	shares, err := s.stakingController.GetStakeInfo(nil, prover, prover)
+	if err != nil {
+		return nil, fmt.Errorf("GetStakeInfo: %w", err)
+	}
+	vaultAddr, err := s.stakingController.GetProverVault(nil, prover)
+	if err != nil {
+		return nil, fmt.Errorf("GetProverVault: %w", err)
+	}
+	vault, err := bindings.NewIProverVault(vaultAddr, s.ec)
+	chkErr(err, "NewIProverVault")
+	assets, err := vault.ConvertToAssets(nil, shares)
+	if err != nil {
+		return nil, fmt.Errorf("vault convertToAssets: %w", err)
+	}
+	return assets, nil
+}
+
+// proverSummary describes a prover for the confirmation of actions against it
+func proverSummary(s *stakingState, prover common.Address) (string, error) {
+	info, err := s.stakingController.GetProverInfo(nil, prover)
+	if err != nil {
+		return "", fmt.Errorf("GetProverInfo: %w", err)
+	}
+	if info.Vault == ZeroAddr {
+		return "", fmt.Errorf("prover %s is not initialized", prover.Hex())
+	}
+	assets, err := s.stakingController.GetProverTotalAssets(nil, prover)
+	if err != nil {
+		return "", fmt.Errorf("GetProverTotalAssets: %w", err)
+	}
+	unstaking, err := s.stakingController.GetProverTotalUnstaking(nil, prover)
+	if err != nil {
+		return "", fmt.Errorf("GetProverTotalUnstaking: %w", err)
+	}
+	return fmt.Sprintf("prover %s (%s) state %d, %s stakers, assets %s, unstaking %s",
+		prover.Hex(), info.Name, info.State, info.NumStakers, s.Token.fmt(assets), s.Token.fmt(unstaking)), nil
+}
+
+func adminStakingJail(args []string) error {
+	s, chid, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	var provers []common.Address
+	var lines []string
+	for _, arg := range args {
+		if !common.IsHexAddress(arg) {
+			return fmt.Errorf("invalid prover address %s", arg)
+		}
+		prover := common.HexToAddress(arg)
+		line, err := proverSummary(s, prover)
+		if err != nil {
+			return err
+		}
+		provers = append(provers, prover)
+		lines = append(lines, line)
+	}
+	call := s.call("jailProvers", provers)
+	if len(provers) == 1 {
+		call = s.call("jailProver", provers[0])
+	}
+	call.Summary = fmt.Sprintf("jail %d provers:\n%s", len(provers), strings.Join(lines, "\n"))
+	call.Destructive = true
+	_, err = execAdminCall(s.ec, s.c, chid, call)
+	return err
+}
+
+func adminStakingSlash(arg string) error {
+	if !common.IsHexAddress(arg) {
+		return fmt.Errorf("invalid prover address %s", arg)
+	}
+	if (slashBps == "") == (slashAmount == "") {
+		return fmt.Errorf("set one of --%s and --%s", FlagBps, FlagAmount)
+	}
+	prover := common.HexToAddress(arg)
+	s, chid, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	summary, err := proverSummary(s, prover)
+	if err != nil {
+		return err
+	}
+	assets, err := s.stakingController.GetProverTotalAssets(nil, prover)
+	chkErr(err, "GetProverTotalAssets")
+
+	var call *adminCall
+	if slashBps != "" {
+		bps, err := parseBps(slashBps, s.MaxSlashBps)
+		if err != nil {
+			return fmt.Errorf("%w, the max-slash-bps", err)
+		}
+		amt := new(big.Int).Mul(assets, bps)
+		amt.Div(amt, bpsDenominator)
+		summary += fmt.Sprintf("\nslash %s of assets, about %s", fmtBps(bps), s.Token.fmt(amt))
+		call = s.call("slash", prover, bps)
+	} else {
+		amt, err := s.Token.parse(slashAmount)
+		if err != nil {
+			return err
+		}
+		maxAmt := new(big.Int).Mul(assets, s.MaxSlashBps)
+		maxAmt.Div(maxAmt, bpsDenominator)
+		if amt.Cmp(maxAmt) > 0 {
+			return fmt.Errorf("amount %s is above %s, the max-slash-bps %s of assets", s.Token.fmt(amt), s.Token.fmt(maxAmt), fmtBps(s.MaxSlashBps))
+		}
+		if assets.Sign() > 0 {
+			bps := new(big.Int).Mul(amt, bpsDenominator)
+			summary += fmt.Sprintf("\nslash %s, about %s of assets", s.Token.fmt(amt), fmtBps(bps.Div(bps, assets)))
+		}
+		call = s.call("slashByAmount", prover, amt)
+	}
+	call.Summary = summary
+	call.Destructive = true
+	_, err = execAdminCall(s.ec, s.c, chid, call)
+	return err
+}
+
+func adminStakingAddRewards(proverArg, amountArg string) error {
+	if !common.IsHexAddress(proverArg) {
+		return fmt.Errorf("invalid prover address %s", proverArg)
+	}
+	prover := common.HexToAddress(proverArg)
+	s, chid, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	amt, err := s.Token.parse(amountArg)
+	if err != nil {
+		return err
+	}
+	if amt.Sign() == 0 {
+		return fmt.Errorf("amount should be larger than 0")
+	}
+	summary, err := proverSummary(s, prover)
+	if err != nil {
+		return err
+	}
+
+	// the rewards are pulled from the sender, which is the safe for a --safe batch
+	sender := s.Owner
+	if adminSafe == "" {
+		_, sender, err = CreateTransactOpts(s.c.Keystore, s.c.Passphrase, chid)
+		chkErr(err, "CreateTransactOpts")
+	}
+	rate, err := s.stakingController.GetCommissionRate(nil, prover, sender)
+	chkErr(err, "GetCommissionRate")
+	commission := new(big.Int).Mul(amt, new(big.Int).SetUint64(rate))
+	commission.Div(commission, bpsDenominator)
+	summary += fmt.Sprintf("\nadd rewards %s: commission %s at %s, stakers %s",
+		s.Token.fmt(amt), s.Token.fmt(commission), fmtBps(new(big.Int).SetUint64(rate)), s.Token.fmt(new(big.Int).Sub(amt, commission)))
+
+	stakingToken, err := bindings.NewIERC20(s.StakingToken, s.ec)
+	chkErr(err, "NewIERC20")
+	allowance, err := stakingToken.Allowance(nil, sender, common.HexToAddress(s.c.StakingControllerAddr))
+	chkErr(err, "Allowance")
+	if allowance.Cmp(amt) < 0 {
+		_, err = execAdminCall(s.ec, s.c, chid, &adminCall{
+			Contract: s.StakingToken,
+			ABI:      bindings.IERC20ABI,
+			Method:   "approve",
+			Args:     []interface{}{common.HexToAddress(s.c.StakingControllerAddr), amt},
+			Summary:  fmt.Sprintf("approve staking controller to pull %s from %s", s.Token.fmt(amt), sender.Hex()),
+			Sender:   sender,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	call := s.call("addRewards0", prover, amt)
+	call.Summary = summary
+	call.Sender = sender
+	_, err = execAdminCall(s.ec, s.c, chid, call)
+	return err
+}
+
+// adminStakingWithdraw sends withdrawTreasury or emergencyRecover, after
+// showing how much of the controller's balance belongs to stakers
+func adminStakingWithdraw(method, toArg, amountArg string) error {
+	if !common.IsHexAddress(toArg) {
+		return fmt.Errorf("invalid recipient address %s", toArg)
+	}
+	to := common.HexToAddress(toArg)
+	s, chid, err := getStakingState()
+	if err != nil {
+		return err
+	}
+	amt, err := s.Token.parse(amountArg)
+	if err != nil {
+		return err
+	}
+	stakingToken, err := bindings.NewIERC20(s.StakingToken, s.ec)
+	chkErr(err, "NewIERC20")
+	balance, err := stakingToken.BalanceOf(nil, common.HexToAddress(s.c.StakingControllerAddr))
+	chkErr(err, "BalanceOf")
+	staked := big.NewInt(0)
+	for _, isActive := range []bool{true, false} {
+		assets, err := s.stakingController.GetTotalVaultAssets(nil, isActive)
+		chkErr(err, "GetTotalVaultAssets")
+		staked.Add(staked, assets)
+	}
+	call := s.call(method, to, amt)
+	call.Summary = fmt.Sprintf("%s %s to %s\ncontroller holds %s, vault assets are %s", method, s.Token.fmt(amt), to.Hex(), s.Token.fmt(balance), s.Token.fmt(staked))
+	if method == "emergencyRecover" {
+		if rest := new(big.Int).Sub(balance, amt); rest.Cmp(staked) < 0 {
+			call.Summary += fmt.Sprintf("\nWARNING: %s would be left, less than the vault assets, stakers could not withdraw in full", s.Token.fmt(rest))
+		}
+	}
+	call.Destructive = true
+	_, err = execAdminCall(s.ec, s.c, chid, call)
+	return err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestCheckUpdatedEvent(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(bindings.BrevisMarketABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	market := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	receipt := func(contract common.Address, event string, args ...interface{}) *types.Receipt {
+		ev := parsed.Events[event]
+		topics := []common.Hash{ev.ID}
+		var data []interface{}
+		for i, arg := range ev.Inputs {
+			if !arg.Indexed {
+				data = append(data, args[i])
+				continue
+			}
+			topic, err := abi.MakeTopics([]interface{}{args[i]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			topics = append(topics, topic[0][0])
+		}
+		packed, err := ev.Inputs.NonIndexed().Pack(data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &types.Receipt{Logs: []*types.Log{{Address: contract, Topics: topics, Data: packed}}}
+	}
+	call := &adminCall{Contract: market, Method: "setSlashBps", Args: []interface{}{big.NewInt(500)}}
+
+	if err = checkUpdatedEvent(&parsed, call, receipt(market, "SlashBpsUpdated", big.NewInt(100), big.NewInt(500))); err != nil {
+		t.Errorf("matching event: %s", err)
+	}
+	if err = checkUpdatedEvent(&parsed, call, receipt(market, "SlashBpsUpdated", big.NewInt(100), big.NewInt(400))); err == nil {
+		t.Error("event with another new value accepted")
+	}
+	if err = checkUpdatedEvent(&parsed, call, receipt(market, "OvercommitBpsUpdated", big.NewInt(100), big.NewInt(500))); err == nil {
+		t.Error("missing event accepted")
+	}
+	other := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	if err = checkUpdatedEvent(&parsed, call, receipt(other, "SlashBpsUpdated", big.NewInt(100), big.NewInt(500))); err == nil {
+		t.Error("event of another contract accepted")
+	}
+
+	// fields named like a call argument are checked too
+	verifier := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	call = &adminCall{Contract: market, Method: "setPicoVerifier", Args: []interface{}{uint32(2), verifier}}
+	if err = checkUpdatedEvent(&parsed, call, receipt(market, "PicoVerifierUpdated", uint32(2), common.Address{}, verifier)); err != nil {
+		t.Errorf("matching verifier event: %s", err)
+	}
+	if err = checkUpdatedEvent(&parsed, call, receipt(market, "PicoVerifierUpdated", uint32(1), common.Address{}, verifier)); err == nil {
+		t.Error("event for another version accepted")
+	}
+
+	// calls without an Updated event are not checked
+	call = &adminCall{Contract: market, Method: "withdrawProtocolFee", Args: []interface{}{other}}
+	if err = checkUpdatedEvent(&parsed, call, &types.Receipt{}); err != nil {
+		t.Errorf("withdrawProtocolFee: %s", err)
+	}
+}
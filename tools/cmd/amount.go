@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// token is an ERC20 whose amounts are parsed and printed in both its own
+// units and raw wei
+type token struct {
+	Addr     common.Address
+	Symbol   string
+	Decimals uint8
+}
+
+func getToken(ec bind.ContractCaller, addr common.Address) (*token, error) {
+	meta, err := bindings.NewIERC20MetadataCaller(addr, ec)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := meta.Symbol(nil)
+	if err != nil {
+		return nil, fmt.Errorf("token %s symbol: %w", addr.Hex(), err)
+	}
+	decimals, err := meta.Decimals(nil)
+	if err != nil {
+		return nil, fmt.Errorf("token %s decimals: %w", addr.Hex(), err)
+	}
+	return &token{Addr: addr, Symbol: symbol, Decimals: decimals}, nil
+}
+
+// getStakingToken needs c.StakingTokenAddr, which checkNetwork fills
+func getStakingToken(ec bind.ContractCaller, c *ChainConfig) (*token, error) {
+	return getToken(ec, common.HexToAddress(c.StakingTokenAddr))
+}
+
+func getFeeToken(ec bind.ContractCaller, c *ChainConfig) (*token, error) {
+	brevisMarket, err := bindings.NewBrevisMarketCaller(common.HexToAddress(c.BrevisMarketAddr), ec)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := brevisMarket.FeeToken(nil)
+	if err != nil {
+		return nil, fmt.Errorf("FeeToken: %w", err)
+	}
+	return getToken(ec, addr)
+}
+
+func (t *token) unit() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)
+}
+
+// parse accepts an amount in token units, eg. "1000 BREV" or "1.5e3 BREV", or
+// raw wei, eg. "1000000000000000000000", "0x3635c9adc5dea00000" or "1e21 wei".
+// Without a unit, only a plain integer or hex is taken, as wei. A decimal or
+// exponent needs a unit, as it is unclear whether it means tokens or wei.
+// A plain integer without a unit below one token is logged as likely meant in tokens
+func (t *token) parse(s string) (*big.Int, error) {
+	v, bare, err := t.parseAmount(s)
+	if err == nil && bare && v.Sign() > 0 && v.Cmp(t.unit()) < 0 {
+		log.Printf("WARNING: amount %q without a unit is %s wei, less than 1 %s. Write \"%s %s\" for tokens",
+			s, v, t.Symbol, strings.TrimSpace(s), t.Symbol)
+	}
+	return v, err
+}
+
+// parseAmount is parse without the warning, bare is true if s is a plain
+// decimal integer without a unit
+func (t *token) parseAmount(s string) (v *big.Int, bare bool, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, false, fmt.Errorf("invalid amount %q, should be like \"1000 %s\" or raw wei", s, t.Symbol)
+	}
+	num := fields[0]
+	if len(fields) == 1 {
+		hex := strings.HasPrefix(num, "0x") || strings.HasPrefix(num, "0X")
+		if !hex && strings.ContainsAny(num, ".eE") {
+			return nil, false, fmt.Errorf("invalid amount %q, add the unit: \"%s %s\" or \"%s wei\"", s, num, t.Symbol, num)
+		}
+		v, err = t.parseNum(s, num, false)
+		return v, !hex, err
+	}
+	inTokens := false
+	switch {
+	case strings.EqualFold(fields[1], t.Symbol):
+		inTokens = true
+	case strings.EqualFold(fields[1], "wei"):
+	default:
+		return nil, false, fmt.Errorf("invalid amount %q, unit should be %s or wei", s, t.Symbol)
+	}
+	v, err = t.parseNum(s, num, inTokens)
+	return v, false, err
+}
+
+// parseNum parses the number num of amount s, in token units if inTokens else in wei
+func (t *token) parseNum(s, num string, inTokens bool) (*big.Int, error) {
+	if strings.HasPrefix(num, "0x") || strings.HasPrefix(num, "0X") {
+		if inTokens {
+			return nil, fmt.Errorf("invalid amount %q, hex is only for raw wei", s)
+		}
+		return parseUint256(num)
+	}
+	if strings.Contains(num, "/") {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if inTokens {
+		r.Mul(r, new(big.Rat).SetInt(t.unit()))
+		if !r.IsInt() {
+			return nil, fmt.Errorf("invalid amount %q, %s has %d decimals", s, t.Symbol, t.Decimals)
+		}
+	} else if !r.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q, wei should be an integer", s)
+	}
+	if r.Num().BitLen() > 256 {
+		return nil, fmt.Errorf("invalid amount %q, above uint256", s)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// checkAmount checks an amount offline, the unit is checked against the token
+// symbol once the chain is known
+func checkAmount(s string) error {
+	t := &token{Symbol: "TOKEN", Decimals: 18}
+	if fields := strings.Fields(s); len(fields) == 2 && !strings.EqualFold(fields[1], "wei") {
+		t.Symbol = fields[1]
+	}
+	_, _, err := t.parseAmount(s)
+	return err
+}
+
+// human prints v in token units, eg. "1000.5 BREV"
+func (t *token) human(v *big.Int) string {
+	s := new(big.Rat).SetFrac(v, t.unit()).FloatString(int(t.Decimals))
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s + " " + t.Symbol
+}
+
+// fmt prints v like fmtSeconds does, raw first, eg. "1000500000000000000000 (1000.5 BREV)"
+func (t *token) fmt(v *big.Int) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", v, t.human(v))
+}
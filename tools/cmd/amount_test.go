@@ -0,0 +1,165 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+)
+
+var (
+	brev = &token{Symbol: "BREV", Decimals: 18}
+	usdc = &token{Symbol: "USDC", Decimals: 6}
+)
+
+func bigInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		panic(s)
+	}
+	return v
+}
+
+func TestTokenParse(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	cases := []struct {
+		token *token
+		in    string
+		want  string // empty if in is invalid
+	}{
+		{brev, "1000 BREV", "1000000000000000000000"},
+		{brev, "1000 brev", "1000000000000000000000"},
+		{brev, "0.25 BREV", "250000000000000000"},
+		{brev, "1.5e3 BREV", "1500000000000000000000"},
+		{brev, "1000", "1000"},
+		{brev, "1000000000000000000000", "1000000000000000000000"},
+		{brev, "0x3635c9adc5dea00000", "1000000000000000000000"},
+		{brev, "1e21 wei", "1000000000000000000000"},
+		{brev, "12 wei", "12"},
+		{brev, " 7  BREV ", "7000000000000000000"},
+		{brev, "0.000000000000000001 BREV", "1"},
+		{usdc, "20 USDC", "20000000"},
+		{usdc, "0.000001 USDC", "1"},
+		{usdc, "20", "20"},
+		{brev, maxUint256.String(), maxUint256.String()},
+
+		{brev, "", ""},
+		{brev, "1000 USDC", ""},
+		{brev, "1 BREV extra", ""},
+		{brev, "0x10 BREV", ""},
+		{brev, "1.5 wei", ""},
+		{brev, "1e-1 wei", ""},
+		{brev, "0.0000000000000000001 BREV", ""},
+		{usdc, "0.0000001 USDC", ""},
+		{usdc, "1e-7", ""},
+		{brev, "1.5e3", ""},
+		{brev, "0.5", ""},
+		{brev, "1000.0", ""},
+		{brev, "1e3", ""},
+		{usdc, "1.5", ""},
+		{brev, "-1 BREV", ""},
+		{brev, "-5", ""},
+		{brev, "1/2 BREV", ""},
+		{brev, "abc", ""},
+		{brev, new(big.Int).Add(maxUint256, big.NewInt(1)).String(), ""},
+		{brev, "1e78 BREV", ""},
+	}
+	for _, c := range cases {
+		got, err := c.token.parse(c.in)
+		if c.want == "" {
+			if err == nil {
+				t.Errorf("parse %q as %s = %s, want error", c.in, c.token.Symbol, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parse %q as %s: %s", c.in, c.token.Symbol, err)
+		} else if got.String() != c.want {
+			t.Errorf("parse %q as %s = %s, want %s", c.in, c.token.Symbol, got, c.want)
+		}
+	}
+}
+
+func TestTokenHuman(t *testing.T) {
+	cases := []struct {
+		token *token
+		in    string
+		want  string
+	}{
+		{brev, "1000000000000000000000", "1000 BREV"},
+		{brev, "1000500000000000000000", "1000.5 BREV"},
+		{brev, "1", "0.000000000000000001 BREV"},
+		{brev, "0", "0 BREV"},
+		{brev, "-1500000000000000000", "-1.5 BREV"},
+		{usdc, "20000000", "20 USDC"},
+		{usdc, "1234567", "1.234567 USDC"},
+		{usdc, "-250000", "-0.25 USDC"},
+		{&token{Symbol: "RAW", Decimals: 0}, "42", "42 RAW"},
+	}
+	for _, c := range cases {
+		if got := c.token.human(bigInt(c.in)); got != c.want {
+			t.Errorf("human %s as %s = %q, want %q", c.in, c.token.Symbol, got, c.want)
+		}
+	}
+	if got := brev.fmt(bigInt("-1000000000000000000")); got != "-1000000000000000000 (-1 BREV)" {
+		t.Errorf("fmt = %q", got)
+	}
+	if got := brev.fmt(nil); got != "-" {
+		t.Errorf("fmt nil = %q", got)
+	}
+}
+
+func TestCheckAmount(t *testing.T) {
+	for _, in := range []string{"1 BREV", "20 USDC", "1.5e3 BREV", "1000", "1e21 wei", "0x10"} {
+		if err := checkAmount(in); err != nil {
+			t.Errorf("checkAmount %q: %s", in, err)
+		}
+	}
+	for _, in := range []string{"", "1.5 wei", "-1 BREV", "1 2 3", "1.5e3", "0.5"} {
+		if err := checkAmount(in); err == nil {
+			t.Errorf("checkAmount %q succeeded, want error", in)
+		}
+	}
+	// the error names the accepted forms
+	if _, err := brev.parse("1 BREV extra"); err == nil || !strings.Contains(err.Error(), "1000 BREV") {
+		t.Errorf("error %v does not show the expected form", err)
+	}
+	if _, err := brev.parse("1.5e3"); err == nil || !strings.Contains(err.Error(), `"1.5e3 BREV" or "1.5e3 wei"`) {
+		t.Errorf("error %v does not show both units", err)
+	}
+}
+
+func TestTokenParseWarnsBelowOneToken(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	cases := []struct {
+		in   string
+		warn bool
+	}{
+		{"1000", true},
+		{"1000000000000000000", false},
+		{"0", false},
+		{"1000 wei", false},
+		{"1000 BREV", false},
+		{"0x10", false},
+	}
+	for _, c := range cases {
+		out.Reset()
+		if _, err := brev.parse(c.in); err != nil {
+			t.Fatalf("parse %q: %s", c.in, err)
+		}
+		if warned := strings.Contains(out.String(), "WARNING"); warned != c.warn {
+			t.Errorf("parse %q warned %v, want %v: %q", c.in, warned, c.warn, out.String())
+		}
+	}
+	out.Reset()
+	if err := checkAmount("1000"); err != nil || out.Len() > 0 {
+		t.Errorf("checkAmount 1000: %v, logged %q", err, out.String())
+	}
+}
@@ -0,0 +1,349 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagReqId   = "reqid"
+	FlagFee     = "fee"
+	FlagSecrets = "secrets"
+	FlagReplace = "replace"
+)
+
+var (
+	bidReqId    string
+	bidFee      string
+	secretsFile string
+	bidReplace  bool
+)
+
+// BidSecret is what is needed to reveal a sealed bid, losing it means losing the bid
+type BidSecret struct {
+	ReqId    string `json:"reqid"`
+	Prover   string `json:"prover"`
+	Fee      string `json:"fee"`
+	Nonce    string `json:"nonce"`
+	BidHash  string `json:"bid_hash"`
+	BidTx    string `json:"bid_tx,omitempty"`
+	RevealTx string `json:"reveal_tx,omitempty"`
+}
+
+func BidCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bid",
+		Short: "manually commit and reveal sealed bids",
+	}
+	cmd.PersistentFlags().StringVar(&config, FlagConfig, "", "config file path")
+	cmd.PersistentFlags().StringVar(&bidReqId, FlagReqId, "", "request id")
+	cmd.PersistentFlags().StringVar(&secretsFile, FlagSecrets, "bid_secrets.json", "local file storing bid fee and nonce")
+	cmd.MarkPersistentFlagRequired(FlagReqId)
+
+	commit := &cobra.Command{
+		Use:   "commit",
+		Short: "generate a nonce, store the bid secret locally and send Bid",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bidCommit()
+		},
+	}
+	commit.Flags().StringVar(&bidFee, FlagFee, "", "bid fee, eg. \"2.5 BREV\" in fee token units or raw wei")
+	commit.Flags().BoolVar(&bidReplace, FlagReplace, false, "bid again although an earlier bid for the request is not revealed, its secret is kept")
+	commit.MarkFlagRequired(FlagFee)
+
+	reveal := &cobra.Command{
+		Use:   "reveal",
+		Short: "reveal a committed bid from the stored secret",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bidReveal()
+		},
+	}
+
+	cmd.AddCommand(commit, reveal)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(BidCmd())
+}
+
+// BidHash is keccak256(abi.encodePacked(fee, nonce)), as checked by BrevisMarket.reveal
+func BidHash(fee, nonce *big.Int) common.Hash {
+	return crypto.Keccak256Hash(math.U256Bytes(big.NewInt(0).Set(fee)), math.U256Bytes(big.NewInt(0).Set(nonce)))
+}
+
+func bidCommit() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	auth, sender, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+	chkErr(err, "CreateTransactOpts")
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+
+	reqid := common.HexToHash(bidReqId)
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	fee, err := feeToken.parse(bidFee)
+	if err != nil {
+		return fmt.Errorf("fee: %w", err)
+	}
+	req, err := brevisMarket.GetRequest(nil, reqid)
+	chkErr(err, "GetRequest")
+	if req.Timestamp == 0 {
+		return fmt.Errorf("request %s not found", reqid.Hex())
+	}
+	if fee.Cmp(req.MaxFee) > 0 {
+		return fmt.Errorf("fee %s exceeds request max fee %s", feeToken.fmt(fee), feeToken.fmt(req.MaxFee))
+	}
+	biddingPhaseDuration, err := brevisMarket.BiddingPhaseDuration(nil)
+	chkErr(err, "BiddingPhaseDuration")
+	revealPhaseDuration, err := brevisMarket.RevealPhaseDuration(nil)
+	chkErr(err, "RevealPhaseDuration")
+	biddingEnd := req.Timestamp + biddingPhaseDuration
+	if now := uint64(time.Now().Unix()); now >= biddingEnd {
+		return fmt.Errorf("bidding phase ended %s ago", time.Duration(now-biddingEnd)*time.Second)
+	}
+
+	prover, err := bidderProver(brevisMarket, sender)
+	chkErr(err, "bidderProver")
+	nonceBytes := make([]byte, 32)
+	_, err = rand.Read(nonceBytes)
+	chkErr(err, "rand")
+	nonce := big.NewInt(0).SetBytes(nonceBytes)
+	bidHash := BidHash(fee, nonce)
+
+	secrets, err := loadBidSecrets(secretsFile)
+	chkErr(err, "loadBidSecrets")
+	for _, s := range findBidSecrets(secrets, reqid, prover) {
+		if s.RevealTx != "" {
+			continue
+		}
+		if !bidReplace {
+			return fmt.Errorf("request %s already has an unrevealed bid with hash %s, use --%s to bid again", reqid.Hex(), s.BidHash, FlagReplace)
+		}
+		log.Printf("bidding again, the secret of the earlier bid hash %s is kept", s.BidHash)
+	}
+	secret := &BidSecret{
+		ReqId:   reqid.Hex(),
+		Prover:  prover.Hex(),
+		Fee:     fee.String(),
+		Nonce:   nonce.String(),
+		BidHash: bidHash.Hex(),
+	}
+	secrets[bidSecretKey(bidHash)] = secret
+	// persist before sending so the nonce survives a crash
+	err = saveBidSecrets(secretsFile, secrets)
+	chkErr(err, "saveBidSecrets")
+
+	tx, err := brevisMarket.Bid(auth, reqid, bidHash)
+	checkBrevisCustomError(err, "Bid", bindings.IBrevisMarketABI)
+	log.Printf("Bid tx: %s", tx.Hash())
+	secret.BidTx = tx.Hash().Hex()
+	err = saveBidSecrets(secretsFile, secrets)
+	chkErr(err, "saveBidSecrets")
+	receipt, err := bind.WaitMined(context.Background(), ec, tx)
+	chkErr(err, "WaitMined")
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		log.Fatalln("Bid tx status is not success")
+	}
+
+	onchain, err := brevisMarket.GetBidHash(nil, reqid, prover)
+	chkErr(err, "GetBidHash")
+	if onchain != bidHash {
+		log.Fatalf("onchain bid hash %s does not match local %s", common.Hash(onchain).Hex(), bidHash.Hex())
+	}
+	log.Printf("bid of %s committed for prover %s, reveal between %s and %s",
+		feeToken.fmt(fee), prover.Hex(), time.Unix(int64(biddingEnd), 0).UTC().Format(time.RFC3339),
+		time.Unix(int64(biddingEnd+revealPhaseDuration), 0).UTC().Format(time.RFC3339))
+
+	return nil
+}
+
+func bidReveal() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	auth, sender, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+	chkErr(err, "CreateTransactOpts")
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+
+	reqid := common.HexToHash(bidReqId)
+	prover, err := bidderProver(brevisMarket, sender)
+	chkErr(err, "bidderProver")
+	secrets, err := loadBidSecrets(secretsFile)
+	chkErr(err, "loadBidSecrets")
+	stored := findBidSecrets(secrets, reqid, prover)
+	if len(stored) == 0 {
+		return fmt.Errorf("no stored bid secret for request %s prover %s in %s", reqid.Hex(), prover.Hex(), secretsFile)
+	}
+	onchain, err := brevisMarket.GetBidHash(nil, reqid, prover)
+	chkErr(err, "GetBidHash")
+	// the onchain hash picks the secret if the request was bid more than once
+	var secret *BidSecret
+	var fee, nonce *big.Int
+	for _, s := range stored {
+		f, n, err := s.parse()
+		if err != nil {
+			return err
+		}
+		if BidHash(f, n) == onchain {
+			secret, fee, nonce = s, f, n
+			break
+		}
+	}
+	if secret == nil {
+		return fmt.Errorf("onchain bid hash %s matches none of the %d stored secrets", common.Hash(onchain).Hex(), len(stored))
+	}
+
+	req, err := brevisMarket.GetRequest(nil, reqid)
+	chkErr(err, "GetRequest")
+	biddingPhaseDuration, err := brevisMarket.BiddingPhaseDuration(nil)
+	chkErr(err, "BiddingPhaseDuration")
+	revealPhaseDuration, err := brevisMarket.RevealPhaseDuration(nil)
+	chkErr(err, "RevealPhaseDuration")
+	biddingEnd := req.Timestamp + biddingPhaseDuration
+	revealEnd := biddingEnd + revealPhaseDuration
+	now := uint64(time.Now().Unix())
+	if now < biddingEnd {
+		return fmt.Errorf("reveal phase starts in %s", time.Duration(biddingEnd-now)*time.Second)
+	}
+	if now >= revealEnd {
+		return fmt.Errorf("reveal phase ended %s ago", time.Duration(now-revealEnd)*time.Second)
+	}
+
+	tx, err := brevisMarket.Reveal(auth, reqid, fee, nonce)
+	checkBrevisCustomError(err, "Reveal", bindings.IBrevisMarketABI)
+	log.Printf("Reveal tx: %s", tx.Hash())
+	receipt, err := bind.WaitMined(context.Background(), ec, tx)
+	chkErr(err, "WaitMined")
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		log.Fatalln("Reveal tx status is not success")
+	}
+	secret.RevealTx = tx.Hash().Hex()
+	err = saveBidSecrets(secretsFile, secrets)
+	chkErr(err, "saveBidSecrets")
+
+	return nil
+}
+
+// bidderProver returns the prover a bid from sender is recorded for, which is
+// the registered prover if sender is a submitter
+func bidderProver(brevisMarket *bindings.BrevisMarket, sender common.Address) (common.Address, error) {
+	prover, err := brevisMarket.SubmitterToProver(nil, sender)
+	if err != nil {
+		return ZeroAddr, err
+	}
+	if prover == ZeroAddr {
+		return sender, nil
+	}
+	return prover, nil
+}
+
+// bidSecretKey keys secrets by bid hash, so bidding a request again keeps the
+// earlier secret
+func bidSecretKey(bidHash common.Hash) string {
+	return strings.ToLower(bidHash.Hex())
+}
+
+// findBidSecrets returns the stored secrets of bids for reqid by prover,
+// whatever they are keyed by
+func findBidSecrets(secrets map[string]*BidSecret, reqid common.Hash, prover common.Address) []*BidSecret {
+	var found []*BidSecret
+	for _, s := range secrets {
+		if common.HexToHash(s.ReqId) == reqid && common.HexToAddress(s.Prover) == prover {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// parse returns the fee and nonce of s, which may have been edited by hand
+func (s *BidSecret) parse() (fee, nonce *big.Int, err error) {
+	fee, ok := new(big.Int).SetString(s.Fee, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("bid secret %s has invalid fee %q", s.BidHash, s.Fee)
+	}
+	nonce, ok = new(big.Int).SetString(s.Nonce, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("bid secret %s has invalid nonce %q", s.BidHash, s.Nonce)
+	}
+	return fee, nonce, nil
+}
+
+func loadBidSecrets(path string) (map[string]*BidSecret, error) {
+	secrets := make(map[string]*BidSecret)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func saveBidSecrets(path string, secrets map[string]*BidSecret) error {
+	b, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temp file first so a crash never leaves a truncated secrets file
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
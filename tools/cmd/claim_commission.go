@@ -14,7 +14,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 func ClaimCommissionCmd() *cobra.Command {
@@ -26,7 +25,6 @@ func ClaimCommissionCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
-	cmd.MarkFlagRequired(FlagConfig)
 	return cmd
 }
 
@@ -35,13 +33,12 @@ func init() {
 }
 
 func claimCommission() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -50,13 +47,25 @@ func claimCommission() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
-	proverAuth, _, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+	proverAuth, prover, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "prover CreateTransactOpts")
 
 	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
 	chkErr(err, "NewIStakingController")
 
+	token, err := getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
+	info, err := stakingController.GetProverInfo(nil, prover)
+	chkErr(err, "GetProverInfo")
+	log.Printf("claimable commission of prover %s: %s", prover.Hex(), token.fmt(info.PendingCommission))
+	if info.PendingCommission.Sign() == 0 {
+		log.Println("nothing to claim")
+		return nil
+	}
+
 	tx, err := stakingController.ClaimCommission(proverAuth)
 	checkBrevisCustomError(err, "ClaimCommission", bindings.IStakingControllerABI)
 	log.Printf("ClaimCommission tx: %s", tx.Hash())
@@ -65,6 +74,11 @@ func claimCommission() error {
 	if receipt.Status != types.ReceiptStatusSuccessful {
 		log.Fatalln("ClaimCommission tx status is not success")
 	}
+	for _, l := range receipt.Logs {
+		if ev, err := stakingController.ParseCommissionClaimed(*l); err == nil {
+			log.Printf("claimed commission: %s", token.fmt(ev.Amount))
+		}
+	}
 
 	return nil
 }
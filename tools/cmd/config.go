@@ -0,0 +1,183 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// every config field can also be set by a --<section>.<key> flag or a
+// BREVIS_<SECTION>_<KEY> env var, precedence is
+// flag > env > config file > --network profile > built-in default
+const envPrefix = "BREVIS"
+
+var (
+	// configKeys are all <section>.<key> that have a flag and an env var
+	configKeys = map[string]bool{}
+	// setKeys are the configKeys given by flag or env in this run
+	setKeys = map[string]bool{}
+)
+
+func init() {
+	addConfigFlags(rootCmd.PersistentFlags(), "chain", ChainConfig{}, "network")
+	// chain.network has --network as its flag
+	configKeys["chain.network"] = true
+	rootCmd.PersistentPreRunE = bindConfig
+}
+
+// addConfigFlags adds a flag for each field of the section struct cfg, except
+// skip keys and fields that are lists of tables, eg. [[refund_watch.account]]
+func addConfigFlags(flags *pflag.FlagSet, section string, cfg interface{}, skip ...string) {
+	t := reflect.TypeOf(cfg)
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		if key == "" || contains(skip, key) {
+			continue
+		}
+		name := section + "." + key
+		if flags.Lookup(name) != nil {
+			continue
+		}
+		usage := fmt.Sprintf("%s in [%s] of config, env %s", key, section, envName(name))
+		ft := t.Field(i).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.String:
+			flags.String(name, "", usage)
+		case reflect.Uint, reflect.Uint32, reflect.Uint64:
+			flags.Uint64(name, 0, usage)
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			flags.Int64(name, 0, usage)
+		case reflect.Bool:
+			flags.Bool(name, false, usage)
+		case reflect.Slice:
+			if ft.Elem().Kind() != reflect.String {
+				continue
+			}
+			flags.StringSlice(name, nil, usage)
+		default:
+			continue
+		}
+		configKeys[name] = true
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindConfig binds the config keys given by env or by a flag of cmd, so viper
+// reads them before the config file
+func bindConfig(cmd *cobra.Command, args []string) error {
+	for key := range configKeys {
+		if _, ok := os.LookupEnv(envName(key)); !ok {
+			continue
+		}
+		if err := viper.BindEnv(key, envName(key)); err != nil {
+			return err
+		}
+		setKeys[key] = true
+	}
+	var err error
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if !configKeys[f.Name] || err != nil {
+			return
+		}
+		err = viper.BindPFlag(f.Name, f)
+		setKeys[f.Name] = true
+	})
+	return err
+}
+
+// readConfig reads the --config file, if not given every field has to come
+// from flags, env or the network profile
+func readConfig() error {
+	if config == "" {
+		return nil
+	}
+	viper.SetConfigFile(config)
+	return viper.ReadInConfig()
+}
+
+// unmarshalConfig decodes the section from the config file into out, then
+// applies the section keys given by flag or env. If out is a list, eg.
+// [[request]], they apply to every entry, or make the only one if the config
+// has none
+func unmarshalConfig(section string, out interface{}) error {
+	if err := viper.UnmarshalKey(section, out); err != nil {
+		return err
+	}
+	overrides := map[string]interface{}{}
+	var keys []string
+	for key := range setKeys {
+		if strings.HasPrefix(key, section+".") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		overrides[strings.TrimPrefix(key, section+".")] = viper.Get(key)
+	}
+
+	v := reflect.ValueOf(out).Elem()
+	if v.Kind() != reflect.Slice {
+		return decodeOverrides(overrides, out)
+	}
+	if v.Len() == 0 {
+		v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+	}
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			elem = elem.Elem()
+		}
+		if err := decodeOverrides(overrides, elem.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s[%d]: %w", section, i, err)
+		}
+	}
+	return nil
+}
+
+// decodeOverrides decodes like viper does, so "1,2" env works for a list and
+// "10" for a number
+func decodeOverrides(overrides map[string]interface{}, out interface{}) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	return dec.Decode(overrides)
+}
@@ -0,0 +1,359 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagEpoch = "epoch"
+)
+
+var (
+	reportEpochs []uint
+	reportOut    string
+)
+
+// statsEpoch is one entry of BrevisMarket.statsEpochs, EndAt is 0 while it is the last one
+type statsEpoch struct {
+	Id      uint64
+	StartAt uint64
+	EndAt   uint64
+}
+
+// epochProverRow is a prover's line of an epoch leaderboard
+type epochProverRow struct {
+	Prover common.Address
+	Name   string
+	Stats  bindings.IBrevisMarketProverStats
+}
+
+func EpochsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "epochs",
+		Short: "list, schedule and report BrevisMarket stats epochs",
+	}
+	addAccessFlags(cmd)
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "print all stats epochs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return epochsList()
+		},
+	}
+	schedule := &cobra.Command{
+		Use:   "schedule <start>",
+		Short: "schedule a new stats epoch, start is a unix timestamp, RFC3339 time, YYYY-MM-DD (UTC) or duration from now",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return epochsSchedule(args[0])
+		},
+	}
+	pop := &cobra.Command{
+		Use:   "pop",
+		Short: "remove the last scheduled stats epoch if it has not started",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return epochsPop()
+		},
+	}
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "per epoch leaderboard of prover stats",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return epochsReport()
+		},
+	}
+	report.Flags().UintSliceVar(&reportEpochs, FlagEpoch, nil, "epoch ids to report, default to all started epochs")
+	report.Flags().StringVar(&reportOut, FlagOut, "", "also write the leaderboards to this csv file")
+	cmd.AddCommand(list, schedule, pop, report)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(EpochsCmd())
+}
+
+func getStatsEpochs(brevisMarket *bindings.BrevisMarket) ([]*statsEpoch, uint64, error) {
+	n, err := brevisMarket.StatsEpochsLength(nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("StatsEpochsLength: %w", err)
+	}
+	var epochs []*statsEpoch
+	for i := uint64(0); i < n.Uint64(); i++ {
+		e, err := brevisMarket.StatsEpochs(nil, new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, 0, fmt.Errorf("StatsEpochs %d: %w", i, err)
+		}
+		epochs = append(epochs, &statsEpoch{Id: i, StartAt: e.StartAt, EndAt: e.EndAt})
+	}
+	current, err := brevisMarket.StatsEpochId(nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("StatsEpochId: %w", err)
+	}
+	return epochs, current, nil
+}
+
+func (e *statsEpoch) status(current uint64) string {
+	switch {
+	case e.Id < current:
+		return "ended"
+	case e.Id == current:
+		return "current"
+	default:
+		return "scheduled"
+	}
+}
+
+func epochsList() error {
+	_, _, _, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	epochs, current, err := getStatsEpochs(brevisMarket)
+	chkErr(err, "getStatsEpochs")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EPOCH\tSTATUS\tSTART\tEND")
+	for _, e := range epochs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.Id, e.status(current), fmtUnix(e.StartAt), fmtUnix(e.EndAt))
+	}
+	w.Flush()
+	return nil
+}
+
+// epochUpdater is the expected sender of epoch txs, the owner if it holds
+// EPOCH_UPDATER_ROLE, else the first member of the role
+func epochUpdater(brevisMarket *bindings.BrevisMarket) (common.Address, error) {
+	role, err := brevisMarket.EPOCHUPDATERROLE(nil)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("EPOCHUPDATERROLE: %w", err)
+	}
+	owner, err := brevisMarket.Owner(nil)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("Owner: %w", err)
+	}
+	members, err := brevisMarket.RoleMembers(nil, role)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("RoleMembers: %w", err)
+	}
+	for _, m := range members {
+		if m == owner {
+			return owner, nil
+		}
+	}
+	if len(members) == 0 {
+		return ZeroAddr, fmt.Errorf("nobody has EPOCH_UPDATER_ROLE, grant it with roles grant first")
+	}
+	return members[0], nil
+}
+
+func epochsSchedule(arg string) error {
+	startAt, err := parseTimeArg(arg, time.Now())
+	if err != nil {
+		return err
+	}
+	c, ec, chid, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	epochs, current, err := getStatsEpochs(brevisMarket)
+	chkErr(err, "getStatsEpochs")
+	if startAt <= uint64(time.Now().Unix()) {
+		return fmt.Errorf("start %s is not in the future", fmtUnix(startAt))
+	}
+	if len(epochs) > 0 {
+		last := epochs[len(epochs)-1]
+		if startAt <= last.StartAt {
+			return fmt.Errorf("start %s is not after the start of the last epoch %d at %s", fmtUnix(startAt), last.Id, fmtUnix(last.StartAt))
+		}
+	}
+	sender, err := epochUpdater(brevisMarket)
+	if err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("schedule epoch %d starting %s", len(epochs), fmtUnix(startAt))
+	if len(epochs) > 0 {
+		last := epochs[len(epochs)-1]
+		summary += fmt.Sprintf(", which ends epoch %d (%s, started %s)", last.Id, last.status(current), fmtUnix(last.StartAt))
+	}
+	_, err = execAdminCall(ec, c, chid, &adminCall{
+		Contract: common.HexToAddress(c.BrevisMarketAddr),
+		ABI:      bindings.BrevisMarketABI,
+		Method:   "scheduleStatsEpoch",
+		Args:     []interface{}{startAt},
+		Summary:  summary,
+		Sender:   sender,
+	})
+	return err
+}
+
+func epochsPop() error {
+	c, ec, chid, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	epochs, current, err := getStatsEpochs(brevisMarket)
+	chkErr(err, "getStatsEpochs")
+	if len(epochs) == 0 {
+		return fmt.Errorf("no stats epochs")
+	}
+	last := epochs[len(epochs)-1]
+	if last.Id <= current || last.StartAt <= uint64(time.Now().Unix()) {
+		return fmt.Errorf("last epoch %d started at %s, only epochs that have not started can be popped", last.Id, fmtUnix(last.StartAt))
+	}
+	sender, err := epochUpdater(brevisMarket)
+	if err != nil {
+		return err
+	}
+	_, err = execAdminCall(ec, c, chid, &adminCall{
+		Contract: common.HexToAddress(c.BrevisMarketAddr),
+		ABI:      bindings.BrevisMarketABI,
+		Method:   "popStatsEpoch",
+		Summary:  fmt.Sprintf("remove scheduled epoch %d starting %s", last.Id, fmtUnix(last.StartAt)),
+		Sender:   sender,
+	})
+	return err
+}
+
+func epochsReport() error {
+	c, ec, _, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+	epochs, current, err := getStatsEpochs(brevisMarket)
+	chkErr(err, "getStatsEpochs")
+	feeToken, err := getFeeToken(ec, c)
+	chkErr(err, "getFeeToken")
+
+	var ids []uint64
+	for _, id := range reportEpochs {
+		ids = append(ids, uint64(id))
+	}
+	if len(ids) == 0 {
+		for _, e := range epochs {
+			if e.Id <= current {
+				ids = append(ids, e.Id)
+			}
+		}
+	}
+	provers, err := listProvers(stakingController)
+	chkErr(err, "listProvers")
+	names := make(map[common.Address]string)
+	for _, p := range provers {
+		info, err := stakingController.GetProverInfo(nil, p)
+		chkErr(err, "GetProverInfo")
+		names[p] = info.Name
+	}
+
+	var cw *csv.Writer
+	if reportOut != "" {
+		f, err := os.Create(reportOut)
+		chkErr(err, "create csv")
+		defer f.Close()
+		cw = csv.NewWriter(f)
+		cw.Write([]string{"epoch", "start_at", "end_at", "rank", "prover", "name", "requests_fulfilled", "requests_refunded",
+			"bids", "reveals", "fee_received", "fee_share_bps", "last_active_at"})
+	}
+	for _, id := range ids {
+		if id >= uint64(len(epochs)) || id > current {
+			return fmt.Errorf("epoch %d has not started", id)
+		}
+		global, err := brevisMarket.GetGlobalStatsForStatsEpoch(nil, id)
+		chkErr(err, "GetGlobalStatsForStatsEpoch")
+		var rows []*epochProverRow
+		for _, p := range provers {
+			ps, err := brevisMarket.GetProverStatsForStatsEpoch(nil, p, id)
+			chkErr(err, "GetProverStatsForStatsEpoch")
+			if ps.Stats.Bids == 0 && ps.Stats.RequestsFulfilled == 0 && ps.Stats.RequestsRefunded == 0 {
+				continue
+			}
+			rows = append(rows, &epochProverRow{Prover: p, Name: names[p], Stats: ps.Stats})
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].Stats.RequestsFulfilled != rows[j].Stats.RequestsFulfilled {
+				return rows[i].Stats.RequestsFulfilled > rows[j].Stats.RequestsFulfilled
+			}
+			return rows[i].Stats.FeeReceived.Cmp(rows[j].Stats.FeeReceived) > 0
+		})
+
+		fmt.Printf("epoch %d (%s): %s - %s, %d requests, %d fulfilled, fees %s\n", id, epochs[id].status(current),
+			fmtUnix(global.StartAt), fmtUnix(global.EndAt), global.Stats.TotalRequests, global.Stats.TotalFulfilled, feeToken.fmt(global.Stats.TotalFees))
+		writeEpochLeaderboard(os.Stdout, rows, global.Stats.TotalFees, feeToken)
+		fmt.Println()
+		if cw != nil {
+			for i, r := range rows {
+				cw.Write([]string{
+					strconv.FormatUint(id, 10), strconv.FormatUint(global.StartAt, 10), strconv.FormatUint(global.EndAt, 10),
+					strconv.Itoa(i + 1), r.Prover.Hex(), r.Name,
+					strconv.FormatUint(r.Stats.RequestsFulfilled, 10), strconv.FormatUint(r.Stats.RequestsRefunded, 10),
+					strconv.FormatUint(r.Stats.Bids, 10), strconv.FormatUint(r.Stats.Reveals, 10),
+					r.Stats.FeeReceived.String(), feeShareBps(r.Stats.FeeReceived, global.Stats.TotalFees).String(),
+					strconv.FormatUint(r.Stats.LastActiveAt, 10),
+				})
+			}
+		}
+	}
+	if cw != nil {
+		cw.Flush()
+		chkErr(cw.Error(), "write csv")
+		log.Printf("leaderboards written to %s", reportOut)
+	}
+	return nil
+}
+
+func writeEpochLeaderboard(out io.Writer, rows []*epochProverRow, totalFees *big.Int, feeToken *token) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tPROVER\tNAME\tFULFILLED\tREFUNDED\tBIDS\tREVEALS\tFEE_RECEIVED\tFEE_SHARE")
+	for i, r := range rows {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n", i+1, r.Prover.Hex(), r.Name,
+			r.Stats.RequestsFulfilled, r.Stats.RequestsRefunded, r.Stats.Bids, r.Stats.Reveals,
+			feeToken.fmt(r.Stats.FeeReceived), fmtBps(feeShareBps(r.Stats.FeeReceived, totalFees)))
+	}
+	w.Flush()
+}
+
+func feeShareBps(fee, total *big.Int) *big.Int {
+	if total.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	share := new(big.Int).Mul(fee, bpsDenominator)
+	return share.Div(share, total)
+}
+
+// parseTimeArg accepts a RFC3339 time, a YYYY-MM-DD date in UTC, or what parseDeadline accepts
+func parseTimeArg(s string, now time.Time) (uint64, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return uint64(t.Unix()), nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return uint64(t.Unix()), nil
+	}
+	ts, err := parseDeadline(s, now)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a unix timestamp, RFC3339 time, date or duration", s)
+	}
+	return ts, nil
+}
+
+func fmtUnix(ts uint64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(int64(ts), 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,362 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+)
+
+type ExporterConfig struct {
+	ListenAddr string   `mapstructure:"listen_addr"`
+	Interval   uint64   `mapstructure:"interval"`
+	Provers    []string `mapstructure:"provers"`
+	Accounts   []string `mapstructure:"accounts"`
+}
+
+var (
+	proverLabels       = []string{"prover"}
+	proverWindowLabels = []string{"prover", "window"}
+	windowLabels       = []string{"window"}
+
+	descProverInfo           = newDesc("prover_info", "prover name and vault, value is always 1", []string{"prover", "name", "vault"})
+	descProverState          = newDesc("prover_state", "prover state in the staking controller", proverLabels)
+	descProverTotalAssets    = newDesc("prover_total_assets", "total assets of the prover vault in staking token wei", proverLabels)
+	descProverUnstaking      = newDesc("prover_total_unstaking", "assets pending unstake in staking token wei", proverLabels)
+	descProverSlashingScale  = newDesc("prover_slashing_scale", "slashing scale of the prover vault as returned by getProverSlashingScale", proverLabels)
+	descProverPendingComm    = newDesc("prover_pending_commission", "unclaimed commission in staking token wei", proverLabels)
+	descProverNumStakers     = newDesc("prover_num_stakers", "number of stakers", proverLabels)
+	descProverCommissionRate = newDesc("prover_commission_rate_bps", "commission rate in bps per reward source, source is default for the fallback rate", []string{"prover", "source"})
+	descProverPending        = newDesc("prover_pending_requests", "won requests without a proof yet", proverLabels)
+	descProverOverdue        = newDesc("prover_overdue_requests", "won requests past deadline without a proof", proverLabels)
+	descProverSuccessRate    = newDesc("prover_success_rate_bps", "success rate in bps from getProverStatsComposite", proverLabels)
+	descProverBids           = newDesc("prover_bids", "bids in the stats window", proverWindowLabels)
+	descProverReveals        = newDesc("prover_reveals", "reveals in the stats window", proverWindowLabels)
+	descProverFulfilled      = newDesc("prover_requests_fulfilled", "requests fulfilled in the stats window", proverWindowLabels)
+	descProverRefunded       = newDesc("prover_requests_refunded", "won requests refunded in the stats window", proverWindowLabels)
+	descProverFeeReceived    = newDesc("prover_fee_received", "fees received in the stats window in fee token wei", proverWindowLabels)
+	descProverLastActive     = newDesc("prover_last_active_timestamp", "unix time of the last prover activity", proverLabels)
+
+	descMarketRequests      = newDesc("market_requests", "requests in the stats window", windowLabels)
+	descMarketFulfilled     = newDesc("market_requests_fulfilled", "fulfilled requests in the stats window", windowLabels)
+	descMarketFees          = newDesc("market_fees", "fees paid in the stats window in fee token wei", windowLabels)
+	descMarketRecentStart   = newDesc("market_recent_stats_start_timestamp", "unix time the recent stats window starts", nil)
+	descProtocolFeeBps      = newDesc("market_protocol_fee_bps", "protocol fee in bps", nil)
+	descProtocolFeeBalance  = newDesc("market_protocol_fee_balance", "protocol fee not withdrawn yet in fee token wei", nil)
+	descProtocolFeeTotal    = newDesc("market_protocol_fee_cumulative", "protocol fee accrued in fee token wei", nil)
+	descProtocolFeeWithdraw = newDesc("market_protocol_fee_withdrawn", "protocol fee withdrawn in fee token wei", nil)
+	descTotalVaultAssets    = newDesc("staking_total_vault_assets", "assets of all prover vaults in staking token wei", []string{"active"})
+
+	descAccountBalance = newDesc("account_token_balance", "token balance of an account in wei", []string{"account", "token", "token_addr"})
+	descAccountNative  = newDesc("account_native_balance", "native balance of an account in wei", []string{"account"})
+
+	descLastRefresh   = newDesc("exporter_last_refresh_timestamp", "unix time of the last successful refresh", nil)
+	descRefreshErrors = newDesc("exporter_refresh_errors_total", "refreshes that failed since start", nil)
+)
+
+func newDesc(name, help string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc("brevis_"+name, help, labels, nil)
+}
+
+// chainCollector serves the metrics of the last refresh, so scrapes never wait
+// on rpc and a failed refresh keeps the previous values
+type chainCollector struct {
+	ec                *ethclient.Client
+	brevisMarket      *bindings.BrevisMarket
+	marketViewer      *bindings.MarketViewer
+	stakingController *bindings.IStakingController
+	stakingToken      common.Address
+	provers           []common.Address // all provers if empty
+	accounts          []common.Address
+
+	lock          sync.RWMutex
+	metrics       []prometheus.Metric
+	lastRefresh   time.Time
+	refreshErrors uint64
+}
+
+func ExporterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exporter",
+		Short: "serve prover and market state as prometheus metrics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExporter()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(cmd.Flags(), "exporter", ExporterConfig{})
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(ExporterCmd())
+}
+
+func runExporter() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	var e ExporterConfig
+	err = unmarshalConfig("exporter", &e)
+	chkErr(err, "unmarshalConfig")
+	if e.ListenAddr == "" {
+		e.ListenAddr = ":9101"
+	}
+	if e.Interval == 0 {
+		e.Interval = 30
+	}
+
+	cc := &chainCollector{ec: ec, stakingToken: common.HexToAddress(c.StakingTokenAddr)}
+	cc.brevisMarket, err = bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	cc.marketViewer, err = bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+	cc.stakingController, err = bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+	for _, p := range e.Provers {
+		if !common.IsHexAddress(p) {
+			return fmt.Errorf("exporter.provers has invalid address %s", p)
+		}
+		cc.provers = append(cc.provers, common.HexToAddress(p))
+	}
+	for _, a := range e.Accounts {
+		if !common.IsHexAddress(a) {
+			return fmt.Errorf("exporter.accounts has invalid address %s", a)
+		}
+		cc.accounts = append(cc.accounts, common.HexToAddress(a))
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(cc)
+	go func() {
+		ticker := time.NewTicker(time.Duration(e.Interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			if err := cc.refresh(); err != nil {
+				log.Printf("refresh err: %s", err)
+			}
+			<-ticker.C
+		}
+	}()
+
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	log.Printf("serving metrics on %s/metrics, refresh every %ds", e.ListenAddr, e.Interval)
+	return http.ListenAndServe(e.ListenAddr, nil)
+}
+
+// Describe sends nothing, which makes this an unchecked collector as the
+// label values are only known after a refresh
+func (cc *chainCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (cc *chainCollector) Collect(ch chan<- prometheus.Metric) {
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	for _, m := range cc.metrics {
+		ch <- m
+	}
+	if !cc.lastRefresh.IsZero() {
+		ch <- prometheus.MustNewConstMetric(descLastRefresh, prometheus.GaugeValue, float64(cc.lastRefresh.Unix()))
+	}
+	ch <- prometheus.MustNewConstMetric(descRefreshErrors, prometheus.CounterValue, float64(cc.refreshErrors))
+}
+
+// refresh reads everything from chain and swaps the metrics only if all reads succeed
+func (cc *chainCollector) refresh() error {
+	m := &metricSet{}
+	err := cc.collectMarket(m)
+	if err == nil {
+		err = cc.collectProvers(m)
+	}
+	if err == nil {
+		err = cc.collectAccounts(m)
+	}
+
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if err != nil {
+		cc.refreshErrors++
+		return err
+	}
+	cc.metrics = m.metrics
+	cc.lastRefresh = time.Now()
+	return nil
+}
+
+type metricSet struct {
+	metrics []prometheus.Metric
+}
+
+func (m *metricSet) add(desc *prometheus.Desc, v float64, labels ...string) {
+	m.metrics = append(m.metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labels...))
+}
+
+func (m *metricSet) addBig(desc *prometheus.Desc, v *big.Int, labels ...string) {
+	f, _ := new(big.Float).SetInt(v).Float64()
+	m.add(desc, f, labels...)
+}
+
+func (cc *chainCollector) collectMarket(m *metricSet) error {
+	global, err := cc.marketViewer.GetGlobalStatsComposite(nil)
+	if err != nil {
+		return fmt.Errorf("GetGlobalStatsComposite: %w", err)
+	}
+	for window, s := range map[string]bindings.IBrevisMarketGlobalStats{"total": global.Total, "recent": global.Recent} {
+		m.add(descMarketRequests, float64(s.TotalRequests), window)
+		m.add(descMarketFulfilled, float64(s.TotalFulfilled), window)
+		m.addBig(descMarketFees, s.TotalFees, window)
+	}
+	m.add(descMarketRecentStart, float64(global.RecentStartAt))
+
+	feeInfo, err := cc.brevisMarket.GetProtocolFeeInfo(nil)
+	if err != nil {
+		return fmt.Errorf("GetProtocolFeeInfo: %w", err)
+	}
+	m.addBig(descProtocolFeeBps, feeInfo.FeeBps)
+	m.addBig(descProtocolFeeBalance, feeInfo.Balance)
+	cumulative, err := cc.brevisMarket.CumulativeProtocolFee(nil)
+	if err != nil {
+		return fmt.Errorf("CumulativeProtocolFee: %w", err)
+	}
+	m.addBig(descProtocolFeeTotal, cumulative)
+	withdrawn, err := cc.brevisMarket.WithdrawnProtocolFee(nil)
+	if err != nil {
+		return fmt.Errorf("WithdrawnProtocolFee: %w", err)
+	}
+	m.addBig(descProtocolFeeWithdraw, withdrawn)
+
+	for _, isActive := range []bool{true, false} {
+		assets, err := cc.stakingController.GetTotalVaultAssets(nil, isActive)
+		if err != nil {
+			return fmt.Errorf("GetTotalVaultAssets: %w", err)
+		}
+		m.addBig(descTotalVaultAssets, assets, fmt.Sprint(isActive))
+	}
+	return nil
+}
+
+func (cc *chainCollector) collectProvers(m *metricSet) error {
+	provers := cc.provers
+	if len(provers) == 0 {
+		var err error
+		if provers, err = listProvers(cc.stakingController); err != nil {
+			return err
+		}
+	}
+	if len(provers) == 0 {
+		return nil
+	}
+	stats, err := cc.marketViewer.BatchGetProverStatsComposite(nil, provers)
+	if err != nil {
+		return fmt.Errorf("BatchGetProverStatsComposite: %w", err)
+	}
+
+	for i, prover := range provers {
+		p := prover.Hex()
+		info, err := cc.stakingController.GetProverInfo(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetProverInfo %s: %w", p, err)
+		}
+		m.add(descProverInfo, 1, p, info.Name, info.Vault.Hex())
+		m.add(descProverState, float64(info.State), p)
+		m.addBig(descProverPendingComm, info.PendingCommission, p)
+		m.addBig(descProverNumStakers, info.NumStakers, p)
+		m.add(descProverCommissionRate, float64(info.DefaultCommissionRate), p, "default")
+		rates, err := cc.stakingController.GetCommissionRates(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetCommissionRates %s: %w", p, err)
+		}
+		for j, source := range rates.Sources {
+			if j < len(rates.Rates) {
+				m.add(descProverCommissionRate, float64(rates.Rates[j]), p, source.Hex())
+			}
+		}
+
+		assets, err := cc.stakingController.GetProverTotalAssets(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetProverTotalAssets %s: %w", p, err)
+		}
+		m.addBig(descProverTotalAssets, assets, p)
+		unstaking, err := cc.stakingController.GetProverTotalUnstaking(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetProverTotalUnstaking %s: %w", p, err)
+		}
+		m.addBig(descProverUnstaking, unstaking, p)
+		scale, err := cc.stakingController.GetProverSlashingScale(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetProverSlashingScale %s: %w", p, err)
+		}
+		m.addBig(descProverSlashingScale, scale, p)
+
+		s := stats[i]
+		m.add(descProverPending, float64(s.PendingCount), p)
+		m.add(descProverOverdue, float64(s.OverdueCount), p)
+		m.add(descProverSuccessRate, float64(s.SuccessRateBps), p)
+		m.add(descProverLastActive, float64(s.Total.LastActiveAt), p)
+		for window, ps := range map[string]bindings.IBrevisMarketProverStats{"total": s.Total, "recent": s.Recent} {
+			m.add(descProverBids, float64(ps.Bids), p, window)
+			m.add(descProverReveals, float64(ps.Reveals), p, window)
+			m.add(descProverFulfilled, float64(ps.RequestsFulfilled), p, window)
+			m.add(descProverRefunded, float64(ps.RequestsRefunded), p, window)
+			m.addBig(descProverFeeReceived, ps.FeeReceived, p, window)
+		}
+	}
+	return nil
+}
+
+func (cc *chainCollector) collectAccounts(m *metricSet) error {
+	if len(cc.accounts) == 0 {
+		return nil
+	}
+	feeToken, err := cc.brevisMarket.FeeToken(nil)
+	if err != nil {
+		return fmt.Errorf("FeeToken: %w", err)
+	}
+	tokens := map[string]common.Address{"staking": cc.stakingToken, "fee": feeToken}
+	for _, account := range cc.accounts {
+		a := account.Hex()
+		for name, tokenAddr := range tokens {
+			token, err := bindings.NewIERC20(tokenAddr, cc.ec)
+			if err != nil {
+				return fmt.Errorf("NewIERC20: %w", err)
+			}
+			balance, err := token.BalanceOf(nil, account)
+			if err != nil {
+				return fmt.Errorf("BalanceOf %s: %w", a, err)
+			}
+			m.addBig(descAccountBalance, balance, a, name, tokenAddr.Hex())
+		}
+		native, err := cc.ec.BalanceAt(context.Background(), account, nil)
+		if err != nil {
+			return fmt.Errorf("BalanceAt %s: %w", a, err)
+		}
+		m.addBig(descAccountNative, native, a)
+	}
+	return nil
+}
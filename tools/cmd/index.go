@@ -0,0 +1,198 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+	"tools/indexer"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagFollow    = "follow"
+	FlagEvent     = "event"
+	FlagContract  = "contract"
+	FlagProver    = "prover"
+	FlagAccount   = "account"
+	FlagFromBlock = "from-block"
+	FlagToBlock   = "to-block"
+	FlagLimit     = "limit"
+	FlagJson      = "json"
+)
+
+var (
+	follow     bool
+	indexQuery indexer.Query
+	jsonOutput bool
+)
+
+type IndexConfig struct {
+	Db            string `mapstructure:"db"`
+	StartBlock    uint64 `mapstructure:"start_block"`
+	Confirmations uint64 `mapstructure:"confirmations"`
+	BlkDelta      uint64 `mapstructure:"blk_delta"`
+	ReorgDepth    uint64 `mapstructure:"reorg_depth"`
+	Interval      uint64 `mapstructure:"interval"`
+}
+
+func IndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "index market and staking events into a local database",
+	}
+	cmd.PersistentFlags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(cmd.PersistentFlags(), "index", IndexConfig{})
+
+	sync := &cobra.Command{
+		Use:   "sync",
+		Short: "index events from the last checkpoint up to the confirmed head",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return indexSync()
+		},
+	}
+	sync.Flags().BoolVar(&follow, FlagFollow, false, "keep indexing new blocks every index.interval seconds")
+
+	query := &cobra.Command{
+		Use:   "query",
+		Short: "print indexed events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return indexQueryEvents()
+		},
+	}
+	query.Flags().StringVar(&indexQuery.Name, FlagEvent, "", "event name, eg. ProofSubmitted")
+	query.Flags().StringVar(&indexQuery.Contract, FlagContract, "", "BrevisMarket or StakingController")
+	query.Flags().StringVar(&indexQuery.ReqId, FlagReqId, "", "request id")
+	query.Flags().StringVar(&indexQuery.Prover, FlagProver, "", "prover address")
+	query.Flags().StringVar(&indexQuery.Account, FlagAccount, "", "staker, requester, submitter or recipient address")
+	query.Flags().Uint64Var(&indexQuery.FromBlock, FlagFromBlock, 0, "first block")
+	query.Flags().Uint64Var(&indexQuery.ToBlock, FlagToBlock, 0, "last block, 0 for no limit")
+	query.Flags().Uint64Var(&indexQuery.Limit, FlagLimit, 100, "max events to print, 0 for no limit")
+	query.Flags().BoolVar(&jsonOutput, FlagJson, false, "print one json object per event, including all event fields")
+
+	cmd.AddCommand(sync, query)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(IndexCmd())
+}
+
+// readIndexConfig reads chain and index config and fills index defaults
+func readIndexConfig() (*ChainConfig, *IndexConfig, error) {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	var ic IndexConfig
+	err = unmarshalConfig("index", &ic)
+	chkErr(err, "unmarshalConfig")
+	if ic.Db == "" {
+		return nil, nil, fmt.Errorf("index.db is not set")
+	}
+	if ic.Interval == 0 {
+		ic.Interval = 10
+	}
+	return &c, &ic, nil
+}
+
+func indexSync() error {
+	c, ic, err := readIndexConfig()
+	if err != nil {
+		return err
+	}
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, c)
+	chkErr(err, "checkNetwork")
+
+	store, err := indexer.OpenStore(ic.Db)
+	chkErr(err, "OpenStore")
+	defer store.Close()
+	ix, err := indexer.NewIndexer(ec, store, c.ChainID,
+		common.HexToAddress(c.BrevisMarketAddr), common.HexToAddress(c.StakingControllerAddr),
+		indexer.Config{
+			StartBlock: ic.StartBlock,
+			Scanner: scanner.Config{
+				MaxBlkDelta:   ic.BlkDelta,
+				Confirmations: ic.Confirmations,
+				ReorgDepth:    ic.ReorgDepth,
+			},
+		})
+	chkErr(err, "NewIndexer")
+
+	if !follow {
+		blk, err := ix.Sync(context.Background())
+		if err != nil {
+			return err
+		}
+		log.Printf("index is synced to block %d", blk)
+		return nil
+	}
+	ticker := time.NewTicker(time.Duration(ic.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		// errors are usually rpc hiccups, the next sync resumes from the checkpoint
+		if _, err = ix.Sync(context.Background()); err != nil {
+			log.Printf("sync err: %s", err)
+		}
+		<-ticker.C
+	}
+}
+
+func indexQueryEvents() error {
+	c, ic, err := readIndexConfig()
+	if err != nil {
+		return err
+	}
+	store, err := indexer.OpenStore(ic.Db)
+	chkErr(err, "OpenStore")
+	defer store.Close()
+
+	indexQuery.Source = indexer.SourceId(c.ChainID, common.HexToAddress(c.BrevisMarketAddr), common.HexToAddress(c.StakingControllerAddr))
+	cp, err := store.Checkpoint(indexQuery.Source)
+	chkErr(err, "Checkpoint")
+	if cp == nil {
+		return fmt.Errorf("nothing indexed yet for this deployment, run index sync first")
+	}
+	events, err := store.Events(&indexQuery)
+	chkErr(err, "Events")
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range events {
+			if err = enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCK\tTX\tCONTRACT\tEVENT\tREQID\tPROVER\tACCOUNT\tAMOUNT")
+	for _, e := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.BlockNumber, e.TxHash.Hex(), e.Contract, e.Name, e.ReqId, e.Prover, e.Account, e.Amount)
+	}
+	w.Flush()
+	log.Printf("%d events, index is synced to block %d", len(events), cp.BlockNumber)
+	return nil
+}
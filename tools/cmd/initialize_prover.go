@@ -17,7 +17,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type InitializeProverConfig struct {
@@ -38,7 +37,7 @@ func InitializeProverCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
-	cmd.MarkFlagRequired(FlagConfig)
+	addConfigFlags(cmd.Flags(), "init_prover", InitializeProverConfig{})
 	return cmd
 }
 
@@ -47,13 +46,12 @@ func init() {
 }
 
 func initProver() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -62,6 +60,8 @@ func initProver() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
 	proverAuth, prover, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "prover CreateTransactOpts")
@@ -72,8 +72,8 @@ func initProver() error {
 	chkErr(err, "NewIStakingController")
 
 	var s InitializeProverConfig
-	err = viper.UnmarshalKey("init_prover", &s)
-	chkErr(err, "UnmarshalKey")
+	err = unmarshalConfig("init_prover", &s)
+	chkErr(err, "unmarshalConfig")
 
 	approveAmt := big.NewInt(0)
 	var submitterAuth *bind.TransactOpts
@@ -118,10 +118,13 @@ func initProver() error {
 	balance, err := stakingToken.BalanceOf(nil, prover)
 	chkErr(err, "BalanceOf")
 
+	token, err := getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
 	if balance.Cmp(minSelfStake) == -1 {
-		amount := big.NewInt(0).Div(minSelfStake, big.NewInt(1e18))
-		log.Fatalf("You don't have at least %s BREV in your account to meet minimum self-stake requirement", amount.String())
+		log.Fatalf("You don't have at least %s in your account to meet minimum self-stake requirement, balance is %s",
+			token.fmt(minSelfStake), token.fmt(balance))
 	}
+	log.Printf("self-staking %s", token.fmt(minSelfStake))
 
 	tx, err := stakingToken.Approve(proverAuth, common.HexToAddress(c.StakingControllerAddr), approveAmt)
 	chkErr(err, "Approve")
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/viper"
+)
+
+const (
+	FlagNetwork = "network"
+)
+
+var (
+	network string
+)
+
+// networkProfiles are the known deployments, a config [chain] section only
+// has to set what differs, eg. chain_rpc and keystore
+var networkProfiles = map[string]ChainConfig{
+	"mainnet": {
+		ChainID:               8453,
+		ChainRpc:              "https://developer-access-mainnet.base.org",
+		BrevisMarketAddr:      "0xcCec2a9FE35b6B5F23bBF303A4e14e5895DeA127",
+		StakingTokenAddr:      "0x086F405146Ce90135750Bbec9A063a8B20A8bfFb",
+		StakingControllerAddr: "0x9c0D8C5F10f0d3A02D04556a4499964a75DBf4A3",
+		MarketViewerAddr:      "0x0ED8C6e128D1Be1e521B6AcDC25b348829a4Ffd2",
+	},
+	"beta": {
+		ChainID:               8453,
+		ChainRpc:              "https://developer-access-mainnet.base.org",
+		BrevisMarketAddr:      "0x64A364888eeafc0F72e7788DD2fBEc9a456b305e",
+		StakingTokenAddr:      "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913",
+		StakingControllerAddr: "0x435f3Ee9673d6a1c73AddD8F5B6bF643E882E0B3",
+		MarketViewerAddr:      "0xd022DA37cCDAFBC140E32578E8891baE7B2E1AB0",
+	},
+	// a local anvil or hardhat node, contract addresses come from the config
+	// or, except brevis_market_addr, from the chain
+	"local": {
+		ChainID:  31337,
+		ChainRpc: "http://127.0.0.1:8545",
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&network, FlagNetwork, "",
+		fmt.Sprintf("network profile (%s) for chain config fields the config does not set, default to chain.network in config", strings.Join(networkNames(), ", ")))
+}
+
+func networkNames() []string {
+	var names []string
+	for name := range networkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadChainConfig fills c from the --network or chain.network profile, then
+// from the [chain] section, whose fields override the profile's
+func loadChainConfig(c *ChainConfig) error {
+	name := network
+	if name == "" {
+		name = viper.GetString("chain.network")
+	}
+	if name != "" {
+		profile, ok := networkProfiles[name]
+		if !ok {
+			return fmt.Errorf("unknown network %q, should be one of %s", name, strings.Join(networkNames(), ", "))
+		}
+		*c = profile
+	}
+	if err := unmarshalConfig("chain", c); err != nil {
+		return err
+	}
+	c.Network = name
+	return nil
+}
+
+// checkNetwork cross-checks the configured contracts against each other
+// onchain, and fills the staking controller and token if they are not set
+func checkNetwork(ec *ethclient.Client, c *ChainConfig) error {
+	if !common.IsHexAddress(c.BrevisMarketAddr) {
+		return fmt.Errorf("chain.brevis_market_addr %q is not an address, set it in config or use --%s", c.BrevisMarketAddr, FlagNetwork)
+	}
+	market := common.HexToAddress(c.BrevisMarketAddr)
+	brevisMarket, err := bindings.NewBrevisMarket(market, ec)
+	chkErr(err, "NewBrevisMarket")
+	stakingController, err := brevisMarket.StakingController(nil)
+	if err != nil {
+		return fmt.Errorf("chain.brevis_market_addr %s is not a BrevisMarket on chain %d: %w", market.Hex(), c.ChainID, err)
+	}
+	if c.StakingControllerAddr == "" {
+		c.StakingControllerAddr = stakingController.Hex()
+	} else if common.HexToAddress(c.StakingControllerAddr) != stakingController {
+		return fmt.Errorf("chain.staking_controller_addr is %s but BrevisMarket.StakingController() is %s", c.StakingControllerAddr, stakingController.Hex())
+	}
+
+	controller, err := bindings.NewIStakingController(stakingController, ec)
+	chkErr(err, "NewIStakingController")
+	stakingToken, err := controller.StakingToken(nil)
+	if err != nil {
+		return fmt.Errorf("StakingToken: %w", err)
+	}
+	if c.StakingTokenAddr == "" {
+		c.StakingTokenAddr = stakingToken.Hex()
+	} else if common.HexToAddress(c.StakingTokenAddr) != stakingToken {
+		return fmt.Errorf("chain.staking_token_addr is %s but StakingController.StakingToken() is %s", c.StakingTokenAddr, stakingToken.Hex())
+	}
+
+	if c.MarketViewerAddr != "" {
+		marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+		chkErr(err, "NewMarketViewer")
+		viewed, err := marketViewer.BrevisMarket(nil)
+		if err != nil {
+			return fmt.Errorf("chain.market_viewer_addr %s is not a MarketViewer: %w", c.MarketViewerAddr, err)
+		}
+		if viewed != market {
+			return fmt.Errorf("chain.market_viewer_addr %s views BrevisMarket %s, not %s", c.MarketViewerAddr, viewed.Hex(), market.Hex())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,474 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NodeConfig is the bidder config in node-configs/config.toml
+type NodeConfig struct {
+	Global NodeGlobalConfig `mapstructure:"global"`
+	Chain  NodeChainConfig  `mapstructure:"chain"`
+	Rule   NodeRuleConfig   `mapstructure:"rule"`
+}
+
+type NodeGlobalConfig struct {
+	DbUrl     string `mapstructure:"db_url"`
+	ProverUrl string `mapstructure:"prover_url"`
+}
+
+type NodeChainConfig struct {
+	ChainID             uint64 `mapstructure:"chain_id"`
+	ChainRpc            string `mapstructure:"chain_rpc"`
+	BlkInterval         uint64 `mapstructure:"blk_interval"`
+	BlkDelay            uint64 `mapstructure:"blk_delay"`
+	MaxBlkDelta         uint64 `mapstructure:"max_blk_delta"`
+	ForwardBlkDelay     uint64 `mapstructure:"forward_blk_delay"`
+	BrevisMarketAddr    string `mapstructure:"brevis_market_addr"`
+	ProverEthAddr       string `mapstructure:"prover_eth_addr"`
+	SubmitterKeystore   string `mapstructure:"submitter_keystore"`
+	SubmitterPassphrase string `mapstructure:"submitter_passphrase"`
+}
+
+type NodeRuleConfig struct {
+	MaxFee           string   `mapstructure:"max_fee"`
+	MaxInputSize     uint64   `mapstructure:"max_input_size"`
+	ProverGasPrice   string   `mapstructure:"prover_gas_price"`
+	ProveMinDuration uint64   `mapstructure:"prove_min_duration"`
+	VkWhitelist      []string `mapstructure:"vk_whitelist"`
+	VkBlacklist      []string `mapstructure:"vk_blacklist"`
+}
+
+// nodeConfigKind is how a bidder config value has to be written
+type nodeConfigKind int
+
+const (
+	kindString nodeConfigKind = iota
+	kindUint
+	kindUint256 // decimal string, big numbers don't fit toml integers
+	kindAddress
+	kindBytes32List
+)
+
+type nodeConfigKey struct {
+	Key      string
+	Kind     nodeConfigKind
+	Required bool
+	Min      uint64 // for kindUint
+}
+
+var nodeConfigKeys = []nodeConfigKey{
+	{Key: "global.db_url", Kind: kindString, Required: true},
+	{Key: "global.prover_url", Kind: kindString, Required: true},
+	{Key: "chain.chain_id", Kind: kindUint, Required: true, Min: 1},
+	{Key: "chain.chain_rpc", Kind: kindString, Required: true},
+	{Key: "chain.blk_interval", Kind: kindUint, Required: true, Min: 1},
+	{Key: "chain.blk_delay", Kind: kindUint},
+	{Key: "chain.max_blk_delta", Kind: kindUint, Required: true, Min: 1},
+	{Key: "chain.forward_blk_delay", Kind: kindUint},
+	{Key: "chain.brevis_market_addr", Kind: kindAddress, Required: true},
+	{Key: "chain.prover_eth_addr", Kind: kindAddress, Required: true},
+	{Key: "chain.submitter_keystore", Kind: kindString, Required: true},
+	{Key: "chain.submitter_passphrase", Kind: kindString},
+	{Key: "rule.max_fee", Kind: kindUint256},
+	{Key: "rule.max_input_size", Kind: kindUint},
+	{Key: "rule.prover_gas_price", Kind: kindUint256},
+	{Key: "rule.prove_min_duration", Kind: kindUint},
+	{Key: "rule.vk_whitelist", Kind: kindBytes32List},
+	{Key: "rule.vk_blacklist", Kind: kindBytes32List},
+}
+
+// nodeConfigIssue is a check finding, Level is error, warn or ok
+type nodeConfigIssue struct {
+	Level string
+	Key   string
+	Msg   string
+}
+
+type nodeConfigChecker struct {
+	v      *viper.Viper
+	issues []*nodeConfigIssue
+	bad    map[string]bool // keys with a type or format error, skipped by later checks
+}
+
+func NodeConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node-config",
+		Short: "check the bidder node config and simulate its rules",
+	}
+	cmd.PersistentFlags().StringVar(&config, FlagConfig, "", "bidder config file path, eg. ~/.bidder/config.toml")
+	cmd.MarkPersistentFlagRequired(FlagConfig)
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "validate types and ranges, and check chain, market, submitter and prover over rpc",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nodeConfigCheck()
+		},
+	}
+	cmd.AddCommand(check, NodeConfigSimulateCmd())
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(NodeConfigCmd())
+}
+
+func (ck *nodeConfigChecker) add(level, key, format string, args ...interface{}) {
+	ck.issues = append(ck.issues, &nodeConfigIssue{Level: level, Key: key, Msg: fmt.Sprintf(format, args...)})
+	if level == "error" {
+		ck.bad[key] = true
+	}
+}
+
+func (ck *nodeConfigChecker) errors() int {
+	n := 0
+	for _, i := range ck.issues {
+		if i.Level == "error" {
+			n++
+		}
+	}
+	return n
+}
+
+// readNodeConfig reads a bidder config into its own viper, so it does not
+// mix with the tools config
+func readNodeConfig(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return v, nil
+}
+
+func nodeConfigCheck() error {
+	v, err := readNodeConfig(config)
+	if err != nil {
+		return err
+	}
+	ck := &nodeConfigChecker{v: v, bad: make(map[string]bool)}
+	ck.checkKeys()
+	// values are only decoded once their types are right, as decoding is lenient
+	if ck.errors() == 0 {
+		var nc NodeConfig
+		err = v.Unmarshal(&nc)
+		chkErr(err, "Unmarshal")
+		ck.checkRanges(&nc)
+		if ck.errors() == 0 {
+			ck.checkChain(&nc)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEVEL\tKEY\tMESSAGE")
+	for _, i := range ck.issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", i.Level, i.Key, i.Msg)
+	}
+	w.Flush()
+	if n := ck.errors(); n > 0 {
+		return fmt.Errorf("%s has %d errors, the bidder would not bid correctly", config, n)
+	}
+	fmt.Printf("%s is valid\n", config)
+	return nil
+}
+
+// checkKeys checks every known key is present when required and has the
+// right type, and flags keys the bidder does not read
+func (ck *nodeConfigChecker) checkKeys() {
+	known := make(map[string]bool)
+	for _, k := range nodeConfigKeys {
+		known[k.Key] = true
+		raw := ck.v.Get(k.Key)
+		if raw == nil {
+			if k.Required {
+				ck.add("error", k.Key, "missing")
+			}
+			continue
+		}
+		if err := checkNodeConfigValue(k, raw); err != nil {
+			ck.add("error", k.Key, "%s", err)
+		}
+	}
+	var unknown []string
+	for _, key := range ck.v.AllKeys() {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		ck.add("warn", key, "unknown key, ignored by the bidder")
+	}
+}
+
+func checkNodeConfigValue(k nodeConfigKey, raw interface{}) error {
+	switch k.Kind {
+	case kindString:
+		if _, ok := raw.(string); !ok {
+			return fmt.Errorf("should be a string, got %v", raw)
+		}
+	case kindUint:
+		i, ok := raw.(int64)
+		if !ok {
+			return fmt.Errorf("should be an integer, got %q", fmt.Sprint(raw))
+		}
+		if i < 0 || uint64(i) < k.Min {
+			return fmt.Errorf("should be at least %d, got %d", k.Min, i)
+		}
+	case kindUint256:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("should be a quoted decimal string, got %v", raw)
+		}
+		if _, err := parseUint256(s); err != nil {
+			return err
+		}
+	case kindAddress:
+		s, ok := raw.(string)
+		if ok && s == "" {
+			return fmt.Errorf("empty")
+		}
+		if !ok || !common.IsHexAddress(s) {
+			return fmt.Errorf("should be a 0x address, got %q", fmt.Sprint(raw))
+		}
+		if common.HexToAddress(s) == ZeroAddr {
+			return fmt.Errorf("is the zero address")
+		}
+	case kindBytes32List:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("should be a list of 0x bytes32 strings")
+		}
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("item %d should be a string, got %v", i, item)
+			}
+			if _, err := parseBytes32(s); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseBytes32(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != 32 {
+		return common.Hash{}, fmt.Errorf("%q is not 0x and 64 hex digits", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// checkRanges checks values that have the right type but would make the bidder idle or slow
+func (ck *nodeConfigChecker) checkRanges(nc *NodeConfig) {
+	if !ck.bad["global.prover_url"] && nc.Global.ProverUrl != "" {
+		if _, _, err := net.SplitHostPort(nc.Global.ProverUrl); err != nil {
+			// the bidder dials it as a grpc target, docs/run_prover_node.md gives it as ${pico-ip}:${port}
+			ck.add("warn", "global.prover_url", "expected ${pico-ip}:${port} (default port 50052), %s", err)
+		} else if conn, err := net.DialTimeout("tcp", nc.Global.ProverUrl, 3*time.Second); err != nil {
+			ck.add("warn", "global.prover_url", "not reachable from here: %s", err)
+		} else {
+			conn.Close()
+		}
+	} else if nc.Global.ProverUrl == "" && !ck.bad["global.prover_url"] {
+		ck.add("error", "global.prover_url", "empty, the bidder can't prove")
+	}
+	for _, key := range []string{"global.db_url", "chain.chain_rpc", "chain.submitter_keystore"} {
+		if s, ok := ck.v.Get(key).(string); ok && strings.TrimSpace(s) == "" {
+			ck.add("error", key, "empty")
+		}
+	}
+	if nc.Chain.BlkDelay > 64 {
+		ck.add("warn", "chain.blk_delay", "%d blocks behind head, the bidder may see requests too late to bid", nc.Chain.BlkDelay)
+	}
+	if nc.Chain.MaxBlkDelta > 10000 {
+		ck.add("warn", "chain.max_blk_delta", "%d, many rpcs reject log queries over 10000 blocks", nc.Chain.MaxBlkDelta)
+	}
+	// max_fee and prover_gas_price are optional, only set values are checked
+	if !ck.bad["rule.max_fee"] && ck.v.IsSet("rule.max_fee") {
+		if fee, err := parseUint256(nc.Rule.MaxFee); err == nil && fee.Sign() == 0 {
+			ck.add("error", "rule.max_fee", "0, every request would be skipped")
+		}
+	}
+	if !ck.bad["rule.prover_gas_price"] && ck.v.IsSet("rule.prover_gas_price") {
+		if price, err := parseUint256(nc.Rule.ProverGasPrice); err == nil && price.Sign() == 0 {
+			ck.add("warn", "rule.prover_gas_price", "0, bids would be free")
+		}
+	}
+	if ck.bad["rule.vk_whitelist"] || ck.bad["rule.vk_blacklist"] {
+		return
+	}
+	white := make(map[common.Hash]bool)
+	for _, s := range nc.Rule.VkWhitelist {
+		vk, _ := parseBytes32(s)
+		if white[vk] {
+			ck.add("warn", "rule.vk_whitelist", "%s listed twice", vk.Hex())
+		}
+		white[vk] = true
+	}
+	black := make(map[common.Hash]bool)
+	for _, s := range nc.Rule.VkBlacklist {
+		vk, _ := parseBytes32(s)
+		if black[vk] {
+			ck.add("warn", "rule.vk_blacklist", "%s listed twice", vk.Hex())
+		}
+		black[vk] = true
+		if white[vk] {
+			ck.add("warn", "rule.vk_blacklist", "%s is also in vk_whitelist, it is skipped", vk.Hex())
+		}
+	}
+	if len(white) > 0 {
+		allBlack := true
+		for vk := range white {
+			allBlack = allBlack && black[vk]
+		}
+		if allBlack {
+			ck.add("error", "rule.vk_whitelist", "every whitelisted vk is blacklisted, every request would be skipped")
+		}
+	}
+}
+
+// checkChain checks the config against the chain, market and staking state
+func (ck *nodeConfigChecker) checkChain(nc *NodeConfig) {
+	ec, err := ethclient.Dial(nc.Chain.ChainRpc)
+	if err != nil {
+		ck.add("error", "chain.chain_rpc", "dial: %s", err)
+		return
+	}
+	chid, err := ec.ChainID(context.Background())
+	if err != nil {
+		ck.add("error", "chain.chain_rpc", "ChainID: %s", err)
+		return
+	}
+	if chid.Uint64() != nc.Chain.ChainID {
+		ck.add("error", "chain.chain_id", "chainid mismatch! cfg has %d but onchain has %d", nc.Chain.ChainID, chid.Uint64())
+		return
+	}
+	ck.add("ok", "chain.chain_id", "rpc is on chain %d", chid)
+
+	marketAddr := common.HexToAddress(nc.Chain.BrevisMarketAddr)
+	code, err := ec.CodeAt(context.Background(), marketAddr, nil)
+	if err != nil {
+		ck.add("error", "chain.chain_rpc", "CodeAt: %s", err)
+		return
+	}
+	if len(code) == 0 {
+		ck.add("error", "chain.brevis_market_addr", "no contract at %s", marketAddr.Hex())
+		return
+	}
+	brevisMarket, err := bindings.NewBrevisMarket(marketAddr, ec)
+	chkErr(err, "NewBrevisMarket")
+	params, err := getMarketParams(brevisMarket)
+	if err != nil {
+		ck.add("error", "chain.brevis_market_addr", "%s is not a BrevisMarket: %s", marketAddr.Hex(), err)
+		return
+	}
+	ck.add("ok", "chain.brevis_market_addr", "BrevisMarket with bidding phase %s, reveal phase %s",
+		fmtSeconds(params.BiddingPhaseDuration), fmtSeconds(params.RevealPhaseDuration))
+	if nc.Chain.BlkInterval >= params.BiddingPhaseDuration {
+		ck.add("error", "chain.blk_interval", "%ds, not shorter than the bidding phase, bids would be late", nc.Chain.BlkInterval)
+	}
+	// prove_min_duration counts from the end of reveal to the deadline
+	maxProveTime := params.MaxDeadlineDuration - params.BiddingPhaseDuration - params.RevealPhaseDuration
+	if nc.Rule.ProveMinDuration > maxProveTime {
+		ck.add("error", "rule.prove_min_duration", "%s, longer than any request allows after reveal (%s), every request would be skipped",
+			fmtSeconds(nc.Rule.ProveMinDuration), fmtSeconds(maxProveTime))
+	}
+	if ck.bad["chain.prover_eth_addr"] {
+		return
+	}
+	prover := common.HexToAddress(nc.Chain.ProverEthAddr)
+	ck.checkProver(ec, brevisMarket, prover)
+	if !ck.bad["chain.submitter_keystore"] && nc.Chain.SubmitterKeystore != "" {
+		ck.checkSubmitter(brevisMarket, nc, chid, prover)
+	}
+}
+
+func (ck *nodeConfigChecker) checkProver(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, prover common.Address) {
+	addr, err := brevisMarket.StakingController(nil)
+	if err != nil {
+		ck.add("error", "chain.brevis_market_addr", "StakingController: %s", err)
+		return
+	}
+	stakingController, err := bindings.NewIStakingController(addr, ec)
+	chkErr(err, "NewIStakingController")
+	info, err := stakingController.GetProverInfo(nil, prover)
+	if err != nil {
+		ck.add("error", "chain.prover_eth_addr", "GetProverInfo: %s", err)
+		return
+	}
+	if info.State == 0 {
+		ck.add("error", "chain.prover_eth_addr", "%s is not a prover, run init-prover first", prover.Hex())
+		return
+	}
+	eligible, err := stakingController.IsProverEligible(nil, prover, big.NewInt(0))
+	if err != nil {
+		ck.add("error", "chain.prover_eth_addr", "IsProverEligible: %s", err)
+		return
+	}
+	assets := eligible.CurrentVaultAssets.String()
+	if tokenAddr, err := stakingController.StakingToken(nil); err == nil {
+		if token, err := getToken(ec, tokenAddr); err == nil {
+			assets = token.fmt(eligible.CurrentVaultAssets)
+		}
+	}
+	if !eligible.Eligible {
+		ck.add("warn", "chain.prover_eth_addr", "prover %s (state %d) is not eligible to bid, vault assets %s", prover.Hex(), info.State, assets)
+		return
+	}
+	ck.add("ok", "chain.prover_eth_addr", "prover %s is eligible, vault assets %s", info.Name, assets)
+}
+
+// checkSubmitter decrypts the keystore and checks the market records its
+// address as the prover itself or a submitter of it
+func (ck *nodeConfigChecker) checkSubmitter(brevisMarket *bindings.BrevisMarket, nc *NodeConfig, chid *big.Int, prover common.Address) {
+	_, submitter, err := CreateTransactOpts(nc.Chain.SubmitterKeystore, nc.Chain.SubmitterPassphrase, chid)
+	if err != nil {
+		ck.add("error", "chain.submitter_keystore", "can't load with submitter_passphrase: %s", err)
+		return
+	}
+	if submitter == prover {
+		ck.add("ok", "chain.submitter_keystore", "keystore is the prover account %s", submitter.Hex())
+		return
+	}
+	registered, err := brevisMarket.SubmitterToProver(nil, submitter)
+	if err != nil {
+		ck.add("error", "chain.submitter_keystore", "SubmitterToProver: %s", err)
+		return
+	}
+	switch registered {
+	case prover:
+		ck.add("ok", "chain.submitter_keystore", "submitter %s is registered for prover %s", submitter.Hex(), prover.Hex())
+	case ZeroAddr:
+		consent, err := brevisMarket.SubmitterConsent(nil, submitter)
+		if err != nil {
+			ck.add("error", "chain.submitter_keystore", "SubmitterConsent: %s", err)
+			return
+		}
+		if consent == prover {
+			ck.add("error", "chain.submitter_keystore", "submitter %s consented to prover %s, but the prover has not called registerSubmitter", submitter.Hex(), prover.Hex())
+		} else {
+			ck.add("error", "chain.submitter_keystore", "submitter %s is not registered, it should call setSubmitterConsent and then the prover registerSubmitter", submitter.Hex())
+		}
+	default:
+		ck.add("error", "chain.submitter_keystore", "submitter %s is registered for another prover %s", submitter.Hex(), registered.Hex())
+	}
+}
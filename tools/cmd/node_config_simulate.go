@@ -0,0 +1,519 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagCycles = "cycles"
+)
+
+var (
+	simFrom   string
+	simTo     string
+	simOut    string
+	simCycles []string
+)
+
+// bidRule is the [rule] section of the bidder config, parsed
+type bidRule struct {
+	MaxFee           *big.Int // nil if unset, no ceiling then
+	ProverGasPrice   *big.Int // 0 if unset
+	MaxInputSize     uint64
+	ProveMinDuration uint64
+	VkWhitelist      map[common.Hash]bool
+	VkBlacklist      map[common.Hash]bool
+}
+
+// simRequest is a historical request and what the bidder would have done with it
+type simRequest struct {
+	Reqid       common.Hash
+	Block       uint64
+	RequestedAt uint64
+	Vk          common.Hash
+	InputSize   int // -1 if input is only provided by url
+	MaxFee      *big.Int
+	MinStake    *big.Int
+	Deadline    uint64
+
+	Skip   string   // why the bidder would skip it, empty if it would bid
+	BidFee *big.Int // nil if the vk has no --cycles
+
+	Reveals   int
+	LowestFee *big.Int // lowest revealed fee
+	Winner    common.Address
+	ActualFee *big.Int
+	Refunded  bool
+}
+
+func NodeConfigSimulateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "replay historical requests through the [rule] section and report which would be bid on and who won them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nodeConfigSimulate()
+		},
+	}
+	cmd.Flags().StringVar(&simFrom, FlagFrom, "", "first day (YYYY-MM-DD, UTC) or block number")
+	cmd.Flags().StringVar(&simTo, FlagTo, "", "last day (YYYY-MM-DD, UTC) or block number, default to latest block")
+	cmd.Flags().StringSliceVar(&simCycles, FlagCycles, nil, "prove cycles as <vk>=<cycles>, or <cycles> for every other vk, to compute bid fees")
+	cmd.Flags().StringVar(&simOut, FlagOut, "", "also write every request and its decision to this csv file")
+	addBlkDeltaFlag(cmd.Flags())
+	cmd.MarkFlagRequired(FlagFrom)
+	return cmd
+}
+
+func newBidRule(r *NodeRuleConfig) (*bidRule, error) {
+	b := &bidRule{
+		MaxInputSize:     r.MaxInputSize,
+		ProveMinDuration: r.ProveMinDuration,
+		VkWhitelist:      make(map[common.Hash]bool),
+		VkBlacklist:      make(map[common.Hash]bool),
+	}
+	var err error
+	if r.MaxFee != "" {
+		if b.MaxFee, err = parseUint256(r.MaxFee); err != nil {
+			return nil, fmt.Errorf("rule.max_fee: %w", err)
+		}
+	}
+	b.ProverGasPrice = big.NewInt(0)
+	if r.ProverGasPrice != "" {
+		if b.ProverGasPrice, err = parseUint256(r.ProverGasPrice); err != nil {
+			return nil, fmt.Errorf("rule.prover_gas_price: %w", err)
+		}
+	}
+	for _, s := range r.VkWhitelist {
+		vk, err := parseBytes32(s)
+		if err != nil {
+			return nil, fmt.Errorf("rule.vk_whitelist: %w", err)
+		}
+		b.VkWhitelist[vk] = true
+	}
+	for _, s := range r.VkBlacklist {
+		vk, err := parseBytes32(s)
+		if err != nil {
+			return nil, fmt.Errorf("rule.vk_blacklist: %w", err)
+		}
+		b.VkBlacklist[vk] = true
+	}
+	return b, nil
+}
+
+// bidFee is what the bidder bids for a request of cycles, cycles * prover_gas_price / 1e12
+func (b *bidRule) bidFee(cycles uint64) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(cycles), b.ProverGasPrice)
+	return fee.Div(fee, big.NewInt(1e12))
+}
+
+// skip returns why the bidder would not bid on req, empty if it would. The
+// fee checks only apply if the bid fee is known
+func (b *bidRule) skip(req *simRequest, params *marketParams, vaultAssets *big.Int) string {
+	if b.VkBlacklist[req.Vk] {
+		return "vk_blacklist"
+	}
+	if len(b.VkWhitelist) > 0 && !b.VkWhitelist[req.Vk] {
+		return "vk_whitelist"
+	}
+	if b.MaxInputSize > 0 && req.InputSize > 0 && uint64(req.InputSize) > b.MaxInputSize {
+		return "max_input_size"
+	}
+	// remaining time counts from the end of the reveal phase
+	revealEnd := req.RequestedAt + params.BiddingPhaseDuration + params.RevealPhaseDuration
+	if req.Deadline < revealEnd || req.Deadline-revealEnd < b.ProveMinDuration {
+		return "prove_min_duration"
+	}
+	if vaultAssets != nil && vaultAssets.Cmp(req.MinStake) < 0 {
+		return "min_stake"
+	}
+	if req.BidFee != nil {
+		if b.MaxFee != nil && req.BidFee.Cmp(b.MaxFee) > 0 {
+			return "max_fee"
+		}
+		if req.BidFee.Cmp(req.MaxFee) > 0 {
+			return "request_max_fee"
+		}
+	}
+	return ""
+}
+
+// parseCycles parses --cycles into per vk cycles and the default, 0 if unset
+func parseCycles(list []string) (map[common.Hash]uint64, uint64, error) {
+	byVk := make(map[common.Hash]uint64)
+	var def uint64
+	for _, item := range list {
+		vkStr, cyclesStr, ok := strings.Cut(item, "=")
+		if !ok {
+			vkStr, cyclesStr = "", item
+		}
+		cycles, err := strconv.ParseUint(cyclesStr, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid cycles %q", item)
+		}
+		if vkStr == "" {
+			def = cycles
+			continue
+		}
+		vk, err := parseBytes32(vkStr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid cycles %q: %w", item, err)
+		}
+		byVk[vk] = cycles
+	}
+	return byVk, def, nil
+}
+
+func (r *simRequest) outcome() string {
+	switch {
+	case r.Winner != ZeroAddr:
+		return "fulfilled"
+	case r.Refunded:
+		return "refunded"
+	default:
+		return "open"
+	}
+}
+
+// chance compares the bid fee with the lowest revealed fee, lowest bid wins
+func (r *simRequest) chance() string {
+	switch {
+	case r.Skip != "" || r.BidFee == nil:
+		return ""
+	case r.LowestFee == nil:
+		return "uncontested"
+	case r.BidFee.Cmp(r.LowestFee) < 0:
+		return "win"
+	default:
+		return "lose"
+	}
+}
+
+func nodeConfigSimulate() error {
+	v, err := readNodeConfig(config)
+	if err != nil {
+		return err
+	}
+	ck := &nodeConfigChecker{v: v, bad: make(map[string]bool)}
+	ck.checkKeys()
+	// the prover and submitter keys are not needed to simulate
+	for _, k := range nodeConfigKeys {
+		if ck.bad[k.Key] && (strings.HasPrefix(k.Key, "rule.") || k.Key == "chain.chain_id" || k.Key == "chain.chain_rpc" || k.Key == "chain.brevis_market_addr") {
+			return fmt.Errorf("%s has errors, run node-config check", config)
+		}
+	}
+	var nc NodeConfig
+	err = v.Unmarshal(&nc)
+	chkErr(err, "Unmarshal")
+	rule, err := newBidRule(&nc.Rule)
+	if err != nil {
+		return err
+	}
+	cyclesByVk, defaultCycles, err := parseCycles(simCycles)
+	if err != nil {
+		return err
+	}
+
+	ec, err := ethclient.Dial(nc.Chain.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != nc.Chain.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", nc.Chain.ChainID, chid.Uint64())
+	}
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(nc.Chain.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	params, err := getMarketParams(brevisMarket)
+	chkErr(err, "getMarketParams")
+	stakingAddr, err := brevisMarket.StakingController(nil)
+	chkErr(err, "StakingController")
+	stakingController, err := bindings.NewIStakingController(stakingAddr, ec)
+	chkErr(err, "NewIStakingController")
+	feeTokenAddr, err := brevisMarket.FeeToken(nil)
+	chkErr(err, "FeeToken")
+	feeToken, err := getToken(ec, feeTokenAddr)
+	chkErr(err, "getToken")
+
+	// min stake is checked against the prover's current vault assets
+	var vaultAssets *big.Int
+	if common.IsHexAddress(nc.Chain.ProverEthAddr) {
+		eligible, err := stakingController.IsProverEligible(nil, common.HexToAddress(nc.Chain.ProverEthAddr), big.NewInt(0))
+		chkErr(err, "IsProverEligible")
+		vaultAssets = eligible.CurrentVaultAssets
+	}
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	from, err := parseBlockOrDate(ec, simFrom, head, false)
+	if err != nil {
+		return err
+	}
+	to := head
+	if simTo != "" {
+		if to, err = parseBlockOrDate(ec, simTo, head, true); err != nil {
+			return err
+		}
+	}
+	if from > to {
+		return fmt.Errorf("from block %d is after to block %d", from, to)
+	}
+
+	// outcomes come after the request, so they are scanned up to head
+	log.Printf("replaying requests of blocks %d to %d, outcomes up to %d", from, to, head)
+	reqs, err := scanSimRequests(ec, brevisMarket, from, to, head)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		log.Println("no requests in the range")
+		return nil
+	}
+	for _, r := range reqs {
+		cycles, ok := cyclesByVk[r.Vk]
+		if !ok {
+			cycles = defaultCycles
+		}
+		if cycles > 0 {
+			r.BidFee = rule.bidFee(cycles)
+		}
+		r.Skip = rule.skip(r, params, vaultAssets)
+	}
+
+	printSimSummary(reqs, stakingController, params, feeToken)
+	if simOut != "" {
+		err = writeSimCsv(simOut, reqs)
+		chkErr(err, "writeSimCsv")
+		log.Printf("requests written to %s", simOut)
+	}
+	return nil
+}
+
+func scanSimRequests(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, from, to, head uint64) ([]*simRequest, error) {
+	byId := make(map[common.Hash]*simRequest)
+	var reqs []*simRequest
+	blkTime := newBlockTimes(ec)
+	sc := scanner.NewScanner(ec, from, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	err := sc.ScanTo(context.Background(), head, func(r *scanner.Range) error {
+		var found []*simRequest
+		if r.Start <= to {
+			err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketNewRequestIterator, error) {
+				return brevisMarket.FilterNewRequest(opts, nil)
+			}, func(it *bindings.BrevisMarketNewRequestIterator) error {
+				ev := it.Event
+				if ev.Raw.BlockNumber > to {
+					return nil
+				}
+				ts, err := blkTime.get(ev.Raw.BlockNumber)
+				if err != nil {
+					return err
+				}
+				size := len(ev.Req.InputData)
+				if size == 0 {
+					size = -1
+				}
+				found = append(found, &simRequest{
+					Reqid: ev.Reqid, Block: ev.Raw.BlockNumber, RequestedAt: ts, Vk: ev.Req.Vk, InputSize: size,
+					MaxFee: ev.Req.Fee.MaxFee, MinStake: ev.Req.Fee.MinStake, Deadline: ev.Req.Fee.Deadline,
+				})
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("NewRequest: %w", err)
+			}
+		}
+		type reveal struct {
+			reqid common.Hash
+			fee   *big.Int
+		}
+		type proof struct {
+			reqid  common.Hash
+			prover common.Address
+			fee    *big.Int
+		}
+		var reveals []reveal
+		var proofs []proof
+		var refunds []common.Hash
+		err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketBidRevealedIterator, error) {
+			return brevisMarket.FilterBidRevealed(opts, nil, nil)
+		}, func(it *bindings.BrevisMarketBidRevealedIterator) error {
+			reveals = append(reveals, reveal{it.Event.Reqid, it.Event.Fee})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("BidRevealed: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProofSubmittedIterator, error) {
+			return brevisMarket.FilterProofSubmitted(opts, nil, nil)
+		}, func(it *bindings.BrevisMarketProofSubmittedIterator) error {
+			proofs = append(proofs, proof{it.Event.Reqid, it.Event.Prover, it.Event.ActualFee})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ProofSubmitted: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketRefundedIterator, error) {
+			return brevisMarket.FilterRefunded(opts, nil, nil)
+		}, func(it *bindings.BrevisMarketRefundedIterator) error {
+			refunds = append(refunds, it.Event.Reqid)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("Refunded: %w", err)
+		}
+
+		for _, req := range found {
+			byId[req.Reqid] = req
+			reqs = append(reqs, req)
+		}
+		for _, rv := range reveals {
+			if req := byId[rv.reqid]; req != nil {
+				req.Reveals++
+				if req.LowestFee == nil || rv.fee.Cmp(req.LowestFee) < 0 {
+					req.LowestFee = rv.fee
+				}
+			}
+		}
+		for _, p := range proofs {
+			if req := byId[p.reqid]; req != nil {
+				req.Winner, req.ActualFee = p.prover, p.fee
+			}
+		}
+		for _, reqid := range refunds {
+			if req := byId[reqid]; req != nil {
+				req.Refunded = true
+			}
+		}
+		return nil
+	})
+	return reqs, err
+}
+
+func printSimSummary(reqs []*simRequest, stakingController *bindings.IStakingController, params *marketParams, feeToken *token) {
+	skipped := make(map[string]int)
+	atStake, paid, ourFees := big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	var bid, priced, wins int
+	type winner struct {
+		Prover common.Address
+		Won    int
+		Fees   *big.Int
+	}
+	winners := make(map[common.Address]*winner)
+	for _, r := range reqs {
+		if r.Skip != "" {
+			skipped[r.Skip]++
+			continue
+		}
+		bid++
+		atStake.Add(atStake, r.MaxFee)
+		if r.BidFee != nil {
+			priced++
+			ourFees.Add(ourFees, r.BidFee)
+			if c := r.chance(); c == "win" || c == "uncontested" {
+				wins++
+			}
+		}
+		if r.Winner != ZeroAddr {
+			paid.Add(paid, r.ActualFee)
+			if winners[r.Winner] == nil {
+				winners[r.Winner] = &winner{Prover: r.Winner, Fees: big.NewInt(0)}
+			}
+			winners[r.Winner].Won++
+			winners[r.Winner].Fees.Add(winners[r.Winner].Fees, r.ActualFee)
+		}
+	}
+
+	fmt.Printf("%d requests, %d would be bid on, %d skipped\n", len(reqs), bid, len(reqs)-bid)
+	fmt.Printf("phases are the current bidding %s and reveal %s\n\n", fmtSeconds(params.BiddingPhaseDuration), fmtSeconds(params.RevealPhaseDuration))
+	if len(skipped) > 0 {
+		reasons := make([]string, 0, len(skipped))
+		for reason := range skipped {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool { return skipped[reasons[i]] > skipped[reasons[j]] })
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SKIPPED_BY\tREQUESTS")
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "%s\t%d\n", reason, skipped[reason])
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	if bid == 0 {
+		return
+	}
+	fmt.Printf("fees at stake (max_fee of the requests bid on): %s\n", feeToken.fmt(atStake))
+	fmt.Printf("fees paid to their winners: %s\n", feeToken.fmt(paid))
+	if priced > 0 {
+		fmt.Printf("with --cycles, %d bids totalling %s, lower than every revealed bid on %d\n", priced, feeToken.fmt(ourFees), wins)
+	} else {
+		fmt.Println("bid fees not computed, set --cycles to apply max_fee and compare with revealed bids")
+	}
+	if len(winners) == 0 {
+		return
+	}
+	list := make([]*winner, 0, len(winners))
+	for _, w := range winners {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Won != list[j].Won {
+			return list[i].Won > list[j].Won
+		}
+		return list[i].Fees.Cmp(list[j].Fees) > 0
+	})
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WINNER\tNAME\tWON\tFEES")
+	for _, win := range list {
+		name := ""
+		if info, err := stakingController.GetProverInfo(nil, win.Prover); err == nil {
+			name = info.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", win.Prover.Hex(), name, win.Won, feeToken.fmt(win.Fees))
+	}
+	w.Flush()
+}
+
+func writeSimCsv(path string, reqs []*simRequest) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cw := csv.NewWriter(f)
+	cw.Write([]string{"reqid", "block", "requested_at", "vk", "input_size", "max_fee", "min_stake", "deadline",
+		"decision", "skipped_by", "bid_fee", "reveals", "lowest_revealed_fee", "chance", "outcome", "winner", "actual_fee"})
+	for _, r := range reqs {
+		decision := "bid"
+		if r.Skip != "" {
+			decision = "skip"
+		}
+		winner := ""
+		if r.Winner != ZeroAddr {
+			winner = r.Winner.Hex()
+		}
+		cw.Write([]string{r.Reqid.Hex(), strconv.FormatUint(r.Block, 10), strconv.FormatUint(r.RequestedAt, 10), r.Vk.Hex(),
+			strconv.Itoa(r.InputSize), r.MaxFee.String(), r.MinStake.String(), strconv.FormatUint(r.Deadline, 10),
+			decision, r.Skip, fmtOptionalInt(r.BidFee), strconv.Itoa(r.Reveals), fmtOptionalInt(r.LowestFee), r.chance(),
+			r.outcome(), winner, fmtOptionalInt(r.ActualFee)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
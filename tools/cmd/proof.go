@@ -0,0 +1,263 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagProof    = "proof"
+	FlagPrecheck = "precheck"
+)
+
+var (
+	proofReqId string
+	proofFile  string
+	precheck   bool
+)
+
+// PicoProof is a proof produced by the pico proving service. Vk and
+// PublicValues are only set if the proof file carries them
+type PicoProof struct {
+	Proof        [8]*big.Int
+	Vk           *common.Hash
+	PublicValues []byte
+}
+
+// picoProofJson matches the evm proof json of the pico proving service
+type picoProofJson struct {
+	RiscvVKey    string   `json:"riscvVKey"`
+	PublicValues string   `json:"publicValues"`
+	Proof        []string `json:"proof"`
+}
+
+func ProofCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proof",
+		Short: "submit and verify pico proofs produced outside the bidder service",
+	}
+	cmd.AddCommand(ProofSubmitCmd())
+	cmd.AddCommand(ProofVerifyCmd())
+	return cmd
+}
+
+func ProofSubmitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "submit",
+		Short: "submit a proof for a request won by this prover",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return submitProof()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	cmd.Flags().StringVar(&proofReqId, FlagReqId, "", "request id")
+	cmd.Flags().StringVar(&proofFile, FlagProof, "", "proof file from the pico proving service")
+	cmd.Flags().BoolVar(&precheck, FlagPrecheck, true, "verify the proof with the pico verifier via eth_call before submitting")
+	cmd.MarkFlagRequired(FlagReqId)
+	cmd.MarkFlagRequired(FlagProof)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(ProofCmd())
+}
+
+func submitProof() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	auth, sender, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+	chkErr(err, "CreateTransactOpts")
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+
+	proof, err := ReadPicoProof(proofFile)
+	chkErr(err, "ReadPicoProof")
+
+	reqid := common.HexToHash(proofReqId)
+	req, err := brevisMarket.GetRequest(nil, reqid)
+	chkErr(err, "GetRequest")
+	if req.Timestamp == 0 {
+		return fmt.Errorf("request %s not found", reqid.Hex())
+	}
+	if req.Status != ReqStatusPending {
+		return fmt.Errorf("request %s is %s", reqid.Hex(), reqStatusName(req.Status))
+	}
+	prover, err := bidderProver(brevisMarket, sender)
+	chkErr(err, "bidderProver")
+	bidders, err := brevisMarket.GetBidders(nil, reqid)
+	chkErr(err, "GetBidders")
+	if bidders.Winner != prover {
+		return fmt.Errorf("request winner is %s, not %s", bidders.Winner.Hex(), prover.Hex())
+	}
+	err = checkProofMatchesRequest(proof, req.Vk, req.PublicValuesDigest)
+	if err != nil {
+		return err
+	}
+
+	if precheck {
+		verifierAddr, err := picoVerifierAddr(brevisMarket, req.Version)
+		if err != nil {
+			return err
+		}
+		err = verifyProofOnchain(ec, verifierAddr, req.Vk, req.PublicValuesDigest, proof.Proof)
+		if err != nil {
+			return err
+		}
+		log.Println("proof precheck passed")
+	}
+
+	tx, err := brevisMarket.SubmitProof(auth, reqid, proof.Proof)
+	checkBrevisCustomError(err, "SubmitProof", bindings.IBrevisMarketABI)
+	log.Printf("SubmitProof tx: %s", tx.Hash())
+	receipt, err := bind.WaitMined(context.Background(), ec, tx)
+	chkErr(err, "WaitMined")
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		log.Fatalln("SubmitProof tx status is not success")
+	}
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	for _, l := range receipt.Logs {
+		if ev, err := brevisMarket.ParseProofSubmitted(*l); err == nil {
+			log.Printf("proof submitted by prover %s, actual fee %s", ev.Prover.Hex(), feeToken.fmt(ev.ActualFee))
+		}
+	}
+
+	return nil
+}
+
+// picoVerifierAddr returns the pico verifier registered in BrevisMarket for version
+func picoVerifierAddr(brevisMarket *bindings.BrevisMarket, version uint32) (common.Address, error) {
+	verifierAddr, err := brevisMarket.PicoVerifiers(nil, version)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("PicoVerifiers: %w", err)
+	}
+	if verifierAddr == ZeroAddr {
+		return ZeroAddr, fmt.Errorf("no pico verifier registered for version %d", version)
+	}
+	return verifierAddr, nil
+}
+
+// verifyProofOnchain calls VerifyPicoProof of the verifier via eth_call
+func verifyProofOnchain(backend bind.ContractBackend, verifierAddr common.Address, vk, digest [32]byte, proof [8]*big.Int) error {
+	verifier, err := bindings.NewIPicoVerifier(verifierAddr, backend)
+	if err != nil {
+		return fmt.Errorf("NewIPicoVerifier: %w", err)
+	}
+	err = verifier.VerifyPicoProof0(nil, vk, digest, proof)
+	if err != nil {
+		return fmt.Errorf("proof is rejected by verifier %s: %w", verifierAddr.Hex(), err)
+	}
+	return nil
+}
+
+// checkProofMatchesRequest compares the vk and public values carried by the
+// proof file, if any, with the request
+func checkProofMatchesRequest(proof *PicoProof, vk, digest [32]byte) error {
+	if proof.Vk != nil && *proof.Vk != vk {
+		return fmt.Errorf("proof vk %s does not match request vk %s", proof.Vk.Hex(), common.Hash(vk).Hex())
+	}
+	if proof.PublicValues != nil && PublicValuesDigest(proof.PublicValues) != digest {
+		return fmt.Errorf("proof public values digest %s does not match request digest %s",
+			PublicValuesDigest(proof.PublicValues).Hex(), common.Hash(digest).Hex())
+	}
+	return nil
+}
+
+// ReadPicoProof parses a proof file, which is either the pico evm proof json
+// ({"riscvVKey", "publicValues", "proof": [8 words]}), a json array of 8
+// words, 256 bytes of hex, or 8 words separated by whitespace or commas.
+// Words are hex with 0x prefix or decimal.
+func ReadPicoProof(path string) (*PicoProof, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(string(b))
+
+	switch {
+	case strings.HasPrefix(content, "{"):
+		var j picoProofJson
+		if err = json.Unmarshal([]byte(content), &j); err != nil {
+			return nil, fmt.Errorf("invalid proof json: %w", err)
+		}
+		proof := &PicoProof{}
+		if proof.Proof, err = parseProofWords(j.Proof); err != nil {
+			return nil, err
+		}
+		if j.RiscvVKey != "" {
+			vk := common.HexToHash(j.RiscvVKey)
+			proof.Vk = &vk
+		}
+		if j.PublicValues != "" {
+			if proof.PublicValues, err = hexutil.Decode(j.PublicValues); err != nil {
+				return nil, fmt.Errorf("invalid publicValues: %w", err)
+			}
+		}
+		return proof, nil
+	case strings.HasPrefix(content, "["):
+		var words []string
+		if err = json.Unmarshal([]byte(content), &words); err != nil {
+			return nil, fmt.Errorf("invalid proof json array: %w", err)
+		}
+		words8, err := parseProofWords(words)
+		return &PicoProof{Proof: words8}, err
+	}
+
+	if raw, err := hexutil.Decode(content); err == nil && len(raw) == 256 {
+		var proof [8]*big.Int
+		for i := range proof {
+			proof[i] = big.NewInt(0).SetBytes(raw[i*32 : (i+1)*32])
+		}
+		return &PicoProof{Proof: proof}, nil
+	}
+	words8, err := parseProofWords(strings.FieldsFunc(content, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t' || r == '\r'
+	}))
+	return &PicoProof{Proof: words8}, err
+}
+
+func parseProofWords(words []string) ([8]*big.Int, error) {
+	var proof [8]*big.Int
+	if len(words) != 8 {
+		return proof, fmt.Errorf("proof should have 8 words, got %d", len(words))
+	}
+	for i, w := range words {
+		v, ok := big.NewInt(0).SetString(strings.TrimSpace(w), 0)
+		if !ok || v.Sign() < 0 || v.BitLen() > 256 {
+			return proof, fmt.Errorf("proof word %d %q is not a valid uint256", i, w)
+		}
+		proof[i] = v
+	}
+	return proof, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagDigest           = "digest"
+	FlagVerifier         = "verifier"
+	FlagSimulated        = "simulated"
+	FlagVerifierBytecode = "verifier-bytecode"
+)
+
+var (
+	verifyVk               string
+	verifyDigest           string
+	verifyPublicValuesFile string
+	verifyVersion          uint32
+	verifierOverride       string
+	simulated              bool
+	verifierBytecodeFile   string
+)
+
+func ProofVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify a proof against a pico verifier via eth_call without spending gas",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyProof()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path, not needed with --simulated")
+	cmd.Flags().StringVar(&proofFile, FlagProof, "", "proof file from the pico proving service")
+	cmd.Flags().StringVar(&proofReqId, FlagReqId, "", "request id to take vk, digest and version from")
+	cmd.Flags().StringVar(&verifyVk, FlagVk, "", "vk, if no reqid")
+	cmd.Flags().StringVar(&verifyDigest, FlagDigest, "", "public values digest, if no reqid")
+	cmd.Flags().StringVar(&verifyPublicValuesFile, FlagPublicValues, "", "public values file to compute the digest from, if no reqid")
+	cmd.Flags().Uint32Var(&verifyVersion, FlagVersion, 0, "pico verifier version, if no reqid")
+	cmd.Flags().StringVar(&verifierOverride, FlagVerifier, "", "verifier address to use instead of BrevisMarket.picoVerifiers(version)")
+	cmd.Flags().BoolVar(&simulated, FlagSimulated, false, "deploy the verifier on an in-process simulated chain instead of using rpc")
+	cmd.Flags().StringVar(&verifierBytecodeFile, FlagVerifierBytecode, "", "verifier creation bytecode (hex or forge artifact json) for --simulated")
+	cmd.MarkFlagRequired(FlagProof)
+	return cmd
+}
+
+func verifyProof() error {
+	proof, err := ReadPicoProof(proofFile)
+	chkErr(err, "ReadPicoProof")
+
+	if simulated {
+		vk, digest, err := proofTarget(proof)
+		if err != nil {
+			return err
+		}
+		if verifierBytecodeFile == "" {
+			return fmt.Errorf("--%s is required with --%s", FlagVerifierBytecode, FlagSimulated)
+		}
+		sim, verifierAddr, err := deploySimulatedVerifier(verifierBytecodeFile)
+		chkErr(err, "deploySimulatedVerifier")
+		defer sim.Close()
+		err = verifyProofOnchain(sim, verifierAddr, vk, digest, proof.Proof)
+		if err != nil {
+			return err
+		}
+		log.Printf("proof is valid on simulated verifier %s", verifierAddr.Hex())
+		return nil
+	}
+
+	err = readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+
+	var vk, digest [32]byte
+	version := verifyVersion
+	if proofReqId != "" {
+		reqid := common.HexToHash(proofReqId)
+		req, err := brevisMarket.GetRequest(nil, reqid)
+		chkErr(err, "GetRequest")
+		if req.Timestamp == 0 {
+			return fmt.Errorf("request %s not found", reqid.Hex())
+		}
+		vk, digest, version = req.Vk, req.PublicValuesDigest, req.Version
+		err = checkProofMatchesRequest(proof, vk, digest)
+		if err != nil {
+			return err
+		}
+	} else {
+		vk, digest, err = proofTarget(proof)
+		if err != nil {
+			return err
+		}
+	}
+
+	verifierAddr := common.HexToAddress(verifierOverride)
+	if verifierOverride == "" {
+		verifierAddr, err = picoVerifierAddr(brevisMarket, version)
+		if err != nil {
+			return err
+		}
+	}
+	err = verifyProofOnchain(ec, verifierAddr, vk, digest, proof.Proof)
+	if err != nil {
+		return err
+	}
+	log.Printf("proof is valid on verifier %s (version %d)", verifierAddr.Hex(), version)
+
+	return nil
+}
+
+// proofTarget resolves vk and public values digest from flags, falling back
+// to what the proof file carries
+func proofTarget(proof *PicoProof) (vk, digest common.Hash, err error) {
+	switch {
+	case verifyVk != "":
+		if vk, err = parseVk(verifyVk); err != nil {
+			return
+		}
+	case proof.Vk != nil:
+		vk = *proof.Vk
+	default:
+		err = fmt.Errorf("please provide --%s or --%s", FlagReqId, FlagVk)
+		return
+	}
+
+	switch {
+	case verifyDigest != "":
+		digest = common.HexToHash(verifyDigest)
+	case verifyPublicValuesFile != "":
+		var pv []byte
+		if pv, err = readBinaryOrHexFile(verifyPublicValuesFile); err != nil {
+			return
+		}
+		digest = PublicValuesDigest(pv)
+	case proof.PublicValues != nil:
+		digest = PublicValuesDigest(proof.PublicValues)
+	default:
+		err = fmt.Errorf("please provide --%s, --%s or --%s", FlagReqId, FlagDigest, FlagPublicValues)
+	}
+	return
+}
+
+// deploySimulatedVerifier deploys the verifier bytecode on an in-process chain,
+// the verifier constructor must take no arguments
+func deploySimulatedVerifier(bytecodeFile string) (*backends.SimulatedBackend, common.Address, error) {
+	bytecode, err := readBytecode(bytecodeFile)
+	if err != nil {
+		return nil, ZeroAddr, err
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, ZeroAddr, err
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		return nil, ZeroAddr, err
+	}
+	balance, _ := big.NewInt(0).SetString("1000000000000000000000", 10)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: balance}}, 30_000_000)
+
+	verifierAbi, err := bindings.IPicoVerifierMetaData.GetAbi()
+	if err != nil {
+		sim.Close()
+		return nil, ZeroAddr, err
+	}
+	addr, _, _, err := bind.DeployContract(auth, *verifierAbi, bytecode, sim)
+	if err != nil {
+		sim.Close()
+		return nil, ZeroAddr, fmt.Errorf("DeployContract: %w", err)
+	}
+	sim.Commit()
+	return sim, addr, nil
+}
+
+// readBytecode reads creation bytecode from a hex file or a forge artifact json
+func readBytecode(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(string(b))
+	if strings.HasPrefix(content, "{") {
+		var artifact struct {
+			Bytecode struct {
+				Object string `json:"object"`
+			} `json:"bytecode"`
+		}
+		if err = json.Unmarshal([]byte(content), &artifact); err != nil {
+			return nil, fmt.Errorf("invalid artifact json: %w", err)
+		}
+		content = artifact.Bytecode.Object
+	}
+	if !strings.HasPrefix(content, "0x") {
+		content = "0x" + content
+	}
+	return hexutil.Decode(content)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockVerifierBytecode is creation bytecode of a verifier with the
+// IPicoVerifier abi that only accepts verifyPicoProof(vk, digest, proof) if
+// proof[0] is keccak256(vk, digest), it stands in for the real verifier whose
+// bytecode is not in this repo
+func mockVerifierBytecode() []byte {
+	sel := crypto.Keccak256([]byte("verifyPicoProof(bytes32,bytes32,uint256[8])"))[:4]
+	runtime := []byte{0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c, 0x63} // selector = calldata[0:4]
+	runtime = append(runtime, sel...)
+	runtime = append(runtime,
+		0x14, 0x15, 0x60, 0x23, 0x57, // if selector != sel goto fail
+		0x60, 0x40, 0x60, 0x04, 0x60, 0x00, 0x37, // mem[0:64] = vk, digest
+		0x60, 0x40, 0x60, 0x00, 0x20, // keccak256(mem[0:64])
+		0x60, 0x44, 0x35, // proof[0]
+		0x14, 0x60, 0x28, 0x57, // if equal goto ok
+		0x5b, 0x60, 0x00, 0x80, 0xfd, // fail: revert
+		0x5b, 0x00, // ok: stop
+	)
+	// constructor returns the runtime code appended to it
+	initcode := []byte{0x60, byte(len(runtime)), 0x80, 0x60, 0x0b, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(initcode, runtime...)
+}
+
+func TestVerifyProofOnSimulatedVerifier(t *testing.T) {
+	bytecodeFile := filepath.Join(t.TempDir(), "verifier.hex")
+	err := os.WriteFile(bytecodeFile, []byte(hexutil.Encode(mockVerifierBytecode())), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, verifierAddr, err := deploySimulatedVerifier(bytecodeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	vk := common.HexToHash("0x00399db87f8d0d43e1795c4aebffe8cc58486e41b98371bdf667f3d29ce4476b")
+	digest := PublicValuesDigest([]byte("fib-100"))
+	var proof [8]*big.Int
+	for i := range proof {
+		proof[i] = big.NewInt(int64(i))
+	}
+	proof[0] = new(big.Int).SetBytes(crypto.Keccak256(vk[:], digest[:]))
+	if err = verifyProofOnchain(sim, verifierAddr, vk, digest, proof); err != nil {
+		t.Fatalf("valid proof rejected: %s", err)
+	}
+
+	tampered := proof
+	tampered[0] = new(big.Int).Add(proof[0], big.NewInt(1))
+	if err = verifyProofOnchain(sim, verifierAddr, vk, digest, tampered); err == nil {
+		t.Error("tampered proof accepted")
+	}
+	otherDigest := PublicValuesDigest([]byte("fib-101"))
+	if err = verifyProofOnchain(sim, verifierAddr, vk, otherDigest, proof); err == nil {
+		t.Error("proof accepted for other public values")
+	}
+}
+
+func TestReadBytecodeArtifact(t *testing.T) {
+	want := mockVerifierBytecode()
+	artifact := filepath.Join(t.TempDir(), "Verifier.json")
+	err := os.WriteFile(artifact, []byte(`{"bytecode":{"object":"`+hexutil.Encode(want)+`"}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readBytecode(artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(got) != hexutil.Encode(want) {
+		t.Errorf("bytecode %x, want %x", got, want)
+	}
+}
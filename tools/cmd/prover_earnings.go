@@ -0,0 +1,363 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagFrom = "from"
+	FlagTo   = "to"
+	FlagOut  = "out"
+)
+
+var (
+	earningsProver string
+	earningsFrom   string
+	earningsTo     string
+	earningsOut    string
+)
+
+// dayEarnings is one csv row, amounts are in token wei
+type dayEarnings struct {
+	Proofs            uint64
+	FeeReceived       *big.Int
+	RewardsAdded      *big.Int
+	CommissionEarned  *big.Int
+	RewardsToStakers  *big.Int
+	CommissionClaimed *big.Int
+	MarketSlashes     uint64
+	MarketSlashed     *big.Int
+	StakingSlashes    uint64
+	StakingSlashed    *big.Int
+}
+
+func newDayEarnings() *dayEarnings {
+	return &dayEarnings{
+		FeeReceived:       big.NewInt(0),
+		RewardsAdded:      big.NewInt(0),
+		CommissionEarned:  big.NewInt(0),
+		RewardsToStakers:  big.NewInt(0),
+		CommissionClaimed: big.NewInt(0),
+		MarketSlashed:     big.NewInt(0),
+		StakingSlashed:    big.NewInt(0),
+	}
+}
+
+func (d *dayEarnings) add(o *dayEarnings) {
+	d.Proofs += o.Proofs
+	d.FeeReceived.Add(d.FeeReceived, o.FeeReceived)
+	d.RewardsAdded.Add(d.RewardsAdded, o.RewardsAdded)
+	d.CommissionEarned.Add(d.CommissionEarned, o.CommissionEarned)
+	d.RewardsToStakers.Add(d.RewardsToStakers, o.RewardsToStakers)
+	d.CommissionClaimed.Add(d.CommissionClaimed, o.CommissionClaimed)
+	d.MarketSlashes += o.MarketSlashes
+	d.MarketSlashed.Add(d.MarketSlashed, o.MarketSlashed)
+	d.StakingSlashes += o.StakingSlashes
+	d.StakingSlashed.Add(d.StakingSlashed, o.StakingSlashed)
+}
+
+var earningsCsvHeader = []string{
+	"date", "proofs", "fee_received", "rewards_added", "commission_earned", "rewards_to_stakers",
+	"commission_claimed", "market_slashes", "market_slashed", "staking_slashes", "staking_slashed",
+}
+
+func (d *dayEarnings) csvRow(date string) []string {
+	return []string{
+		date, strconv.FormatUint(d.Proofs, 10), d.FeeReceived.String(), d.RewardsAdded.String(),
+		d.CommissionEarned.String(), d.RewardsToStakers.String(), d.CommissionClaimed.String(),
+		strconv.FormatUint(d.MarketSlashes, 10), d.MarketSlashed.String(),
+		strconv.FormatUint(d.StakingSlashes, 10), d.StakingSlashed.String(),
+	}
+}
+
+func ProverEarningsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "earnings",
+		Short: "per day csv of proof fees, rewards, commission claims and slashes of a prover",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return proverEarnings()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	cmd.Flags().StringVar(&earningsProver, FlagProver, "", "prover address")
+	cmd.Flags().StringVar(&earningsFrom, FlagFrom, "", "first day (YYYY-MM-DD, UTC) or block number")
+	cmd.Flags().StringVar(&earningsTo, FlagTo, "", "last day (YYYY-MM-DD, UTC) or block number, default to latest block")
+	cmd.Flags().StringVar(&earningsOut, FlagOut, "", "csv output file, default to stdout")
+	addBlkDeltaFlag(cmd.Flags())
+	cmd.MarkFlagRequired(FlagProver)
+	cmd.MarkFlagRequired(FlagFrom)
+	return cmd
+}
+
+func proverEarnings() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	if !common.IsHexAddress(earningsProver) {
+		return fmt.Errorf("invalid prover address %s", earningsProver)
+	}
+	prover := common.HexToAddress(earningsProver)
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	from, err := parseBlockOrDate(ec, earningsFrom, head, false)
+	if err != nil {
+		return err
+	}
+	to := head
+	if earningsTo != "" {
+		if to, err = parseBlockOrDate(ec, earningsTo, head, true); err != nil {
+			return err
+		}
+	}
+	if from > to {
+		return fmt.Errorf("from block %d is after to block %d", from, to)
+	}
+	log.Printf("scanning blocks %d to %d for prover %s", from, to, prover.Hex())
+
+	days, err := scanEarnings(ec, brevisMarket, stakingController, prover, from, to)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if earningsOut != "" {
+		f, err := os.Create(earningsOut)
+		chkErr(err, "create csv")
+		defer f.Close()
+		out = f
+	}
+	total := newDayEarnings()
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	w := csv.NewWriter(out)
+	w.Write(earningsCsvHeader)
+	for _, date := range dates {
+		w.Write(days[date].csvRow(date))
+		total.add(days[date])
+	}
+	w.Write(total.csvRow("total"))
+	w.Flush()
+	chkErr(w.Error(), "write csv")
+
+	// the csv has raw wei, the totals are also logged in token units
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	stakeToken, err := getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
+	log.Printf("total fee received %s, commission earned %s, slashed %s by market and %s by staking",
+		feeToken.fmt(total.FeeReceived), stakeToken.fmt(total.CommissionEarned), stakeToken.fmt(total.MarketSlashed), stakeToken.fmt(total.StakingSlashed))
+
+	// lifetime numbers from contract state to cross check the event totals
+	stats, err := brevisMarket.GetProverStatsTotal(nil, prover)
+	chkErr(err, "GetProverStatsTotal")
+	info, err := stakingController.GetProverInfo(nil, prover)
+	chkErr(err, "GetProverInfo")
+	log.Printf("lifetime fee received (ProverStats.feeReceived): %s, claimable commission now: %s", feeToken.fmt(stats.FeeReceived), stakeToken.fmt(info.PendingCommission))
+	return nil
+}
+
+// scanEarnings aggregates the prover's events in [from, to] per UTC day
+func scanEarnings(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, stakingController *bindings.IStakingController,
+	prover common.Address, from, to uint64) (map[string]*dayEarnings, error) {
+	blkDay := make(map[uint64]string)
+	getDay := func(blk uint64) (string, error) {
+		if d, ok := blkDay[blk]; ok {
+			return d, nil
+		}
+		header, err := ec.HeaderByNumber(context.Background(), big.NewInt(0).SetUint64(blk))
+		if err != nil {
+			return "", fmt.Errorf("HeaderByNumber: %w", err)
+		}
+		blkDay[blk] = time.Unix(int64(header.Time), 0).UTC().Format(time.DateOnly)
+		return blkDay[blk], nil
+	}
+
+	days := make(map[string]*dayEarnings)
+	provers := []common.Address{prover}
+	sc := scanner.NewScanner(ec, from, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	err := sc.ScanTo(context.Background(), to, func(r *scanner.Range) error {
+		// events of the range are merged only once all queries succeeded, as
+		// the scanner retries a range that the rpc rejected
+		rangeDays := make(map[string]*dayEarnings)
+		dayOf := func(blk uint64) (*dayEarnings, error) {
+			date, err := getDay(blk)
+			if err != nil {
+				return nil, err
+			}
+			if rangeDays[date] == nil {
+				rangeDays[date] = newDayEarnings()
+			}
+			return rangeDays[date], nil
+		}
+
+		err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProofSubmittedIterator, error) {
+			return brevisMarket.FilterProofSubmitted(opts, nil, provers)
+		}, func(it *bindings.BrevisMarketProofSubmittedIterator) error {
+			d, err := dayOf(it.Event.Raw.BlockNumber)
+			if err != nil {
+				return err
+			}
+			d.Proofs++
+			d.FeeReceived.Add(d.FeeReceived, it.Event.ActualFee)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ProofSubmitted: %w", err)
+		}
+
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProverSlashedIterator, error) {
+			return brevisMarket.FilterProverSlashed(opts, nil, provers)
+		}, func(it *bindings.BrevisMarketProverSlashedIterator) error {
+			d, err := dayOf(it.Event.Raw.BlockNumber)
+			if err != nil {
+				return err
+			}
+			d.MarketSlashes++
+			d.MarketSlashed.Add(d.MarketSlashed, it.Event.SlashAmount)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("BrevisMarket ProverSlashed: %w", err)
+		}
+
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerRewardsAddedIterator, error) {
+			return stakingController.FilterRewardsAdded(opts, provers, nil)
+		}, func(it *bindings.IStakingControllerRewardsAddedIterator) error {
+			d, err := dayOf(it.Event.Raw.BlockNumber)
+			if err != nil {
+				return err
+			}
+			d.RewardsAdded.Add(d.RewardsAdded, it.Event.Amount)
+			d.CommissionEarned.Add(d.CommissionEarned, it.Event.Commission)
+			d.RewardsToStakers.Add(d.RewardsToStakers, it.Event.ToStakers)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("RewardsAdded: %w", err)
+		}
+
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerCommissionClaimedIterator, error) {
+			return stakingController.FilterCommissionClaimed(opts, provers)
+		}, func(it *bindings.IStakingControllerCommissionClaimedIterator) error {
+			d, err := dayOf(it.Event.Raw.BlockNumber)
+			if err != nil {
+				return err
+			}
+			d.CommissionClaimed.Add(d.CommissionClaimed, it.Event.Amount)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("CommissionClaimed: %w", err)
+		}
+
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerProverSlashedIterator, error) {
+			return stakingController.FilterProverSlashed(opts, provers)
+		}, func(it *bindings.IStakingControllerProverSlashedIterator) error {
+			d, err := dayOf(it.Event.Raw.BlockNumber)
+			if err != nil {
+				return err
+			}
+			d.StakingSlashes++
+			d.StakingSlashed.Add(d.StakingSlashed, it.Event.Amount)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("StakingController ProverSlashed: %w", err)
+		}
+
+		for date, d := range rangeDays {
+			if days[date] == nil {
+				days[date] = newDayEarnings()
+			}
+			days[date].add(d)
+		}
+		return nil
+	})
+	return days, err
+}
+
+// parseBlockOrDate parses a block number, or a UTC date which resolves to the
+// first block of the day, or the last one if endOfDay
+func parseBlockOrDate(ec *ethclient.Client, s string, head uint64, endOfDay bool) (uint64, error) {
+	if blk, err := strconv.ParseUint(s, 10, 64); err == nil {
+		if blk > head {
+			return 0, fmt.Errorf("block %d is after latest block %d", blk, head)
+		}
+		return blk, nil
+	}
+	day, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither a block number nor a YYYY-MM-DD date", s)
+	}
+	if !endOfDay {
+		blk, err := blockAtTime(ec, uint64(day.Unix()), head)
+		if err == nil && blk > head {
+			return 0, fmt.Errorf("%s is after the latest block", s)
+		}
+		return blk, err
+	}
+	next, err := blockAtTime(ec, uint64(day.AddDate(0, 0, 1).Unix()), head)
+	if err != nil || next == 0 {
+		return 0, err
+	}
+	return next - 1, nil
+}
+
+// blockAtTime returns the first block with timestamp >= t, or head+1 if there is none
+func blockAtTime(ec *ethclient.Client, t, head uint64) (uint64, error) {
+	lo, hi := uint64(0), head+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header, err := ec.HeaderByNumber(context.Background(), big.NewInt(0).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("HeaderByNumber: %w", err)
+		}
+		if header.Time < t {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+type ProverWatchConfig struct {
+	Prover      string `mapstructure:"prover"`
+	Interval    uint64 `mapstructure:"interval"`
+	AlertBefore uint64 `mapstructure:"alert_before"`
+	WebhookUrl  string `mapstructure:"webhook_url"`
+}
+
+const (
+	AlertNearDeadline    = "near_deadline"
+	AlertOverdueIncrease = "overdue_increase"
+)
+
+// Alert is what notifiers receive, and is posted as json to webhooks
+type Alert struct {
+	Kind     string `json:"kind"`
+	Prover   string `json:"prover"`
+	ReqId    string `json:"reqid,omitempty"`
+	Deadline uint64 `json:"deadline,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Notifier delivers alerts, implement it to add another alert channel
+type Notifier interface {
+	Notify(a *Alert) error
+}
+
+type stdoutNotifier struct{}
+
+func (stdoutNotifier) Notify(a *Alert) error {
+	log.Printf("ALERT [%s] %s", a.Kind, a.Message)
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *webhookNotifier) Notify(a *Alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// proverViewer is the part of MarketViewer the prover watch polls
+type proverViewer interface {
+	GetProverPendingRequests(opts *bind.CallOpts, prover common.Address) ([]bindings.IMarketViewerProverPendingItem, error)
+	GetProverOverdueCount(opts *bind.CallOpts, prover common.Address) (uint64, error)
+}
+
+// proverWatcher tracks one prover's obligations across polls
+type proverWatcher struct {
+	prover       common.Address
+	marketViewer proverViewer
+	alertBefore  uint64
+	notifiers    []Notifier
+	alerted      map[[32]byte]bool
+	lastOverdue  uint64
+	started      bool
+}
+
+func ProverCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prover",
+		Short: "prover monitoring and reporting",
+	}
+	watch := &cobra.Command{
+		Use:   "watch",
+		Short: "alert when won requests get close to their deadline or become overdue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return proverWatch()
+		},
+	}
+	watch.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(watch.Flags(), "prover_watch", ProverWatchConfig{})
+	cmd.AddCommand(watch)
+	cmd.AddCommand(ProverEarningsCmd())
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(ProverCmd())
+}
+
+func proverWatch() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	var w ProverWatchConfig
+	err = unmarshalConfig("prover_watch", &w)
+	chkErr(err, "unmarshalConfig")
+	if !common.IsHexAddress(w.Prover) {
+		return fmt.Errorf("prover_watch.prover is not a valid address")
+	}
+	if w.Interval == 0 {
+		w.Interval = 60
+	}
+	if w.AlertBefore == 0 {
+		w.AlertBefore = 1800
+	}
+
+	marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+
+	pw := &proverWatcher{
+		prover:       common.HexToAddress(w.Prover),
+		marketViewer: marketViewer,
+		alertBefore:  w.AlertBefore,
+		notifiers:    []Notifier{stdoutNotifier{}},
+		alerted:      make(map[[32]byte]bool),
+	}
+	if w.WebhookUrl != "" {
+		pw.notifiers = append(pw.notifiers, &webhookNotifier{url: w.WebhookUrl, client: &http.Client{Timeout: 10 * time.Second}})
+	}
+	log.Printf("watching prover %s, alert %s before deadline", pw.prover.Hex(), time.Duration(w.AlertBefore)*time.Second)
+
+	ticker := time.NewTicker(time.Duration(w.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err = pw.poll(); err != nil {
+			log.Printf("poll err: %s", err)
+		}
+		<-ticker.C
+	}
+}
+
+// poll checks pending requests, which are won but have no ProofSubmitted yet,
+// and the overdue count
+func (pw *proverWatcher) poll() error {
+	pending, err := pw.marketViewer.GetProverPendingRequests(nil, pw.prover)
+	if err != nil {
+		return fmt.Errorf("GetProverPendingRequests: %w", err)
+	}
+	now := uint64(time.Now().Unix())
+	stillPending := make(map[[32]byte]bool)
+	for _, p := range pending {
+		stillPending[p.Reqid] = true
+		if p.Deadline < now || pw.alerted[p.Reqid] {
+			continue
+		}
+		left := p.Deadline - now
+		if left > pw.alertBefore {
+			continue
+		}
+		pw.alerted[p.Reqid] = true
+		pw.notify(&Alert{
+			Kind:     AlertNearDeadline,
+			Prover:   pw.prover.Hex(),
+			ReqId:    common.Hash(p.Reqid).Hex(),
+			Deadline: p.Deadline,
+			Message: fmt.Sprintf("req %s of prover %s has no proof yet, deadline in %s",
+				common.Hash(p.Reqid).Hex(), pw.prover.Hex(), time.Duration(left)*time.Second),
+		})
+	}
+	// forget requests that are no longer pending
+	for reqid := range pw.alerted {
+		if !stillPending[reqid] {
+			delete(pw.alerted, reqid)
+		}
+	}
+
+	overdue, err := pw.marketViewer.GetProverOverdueCount(nil, pw.prover)
+	if err != nil {
+		return fmt.Errorf("GetProverOverdueCount: %w", err)
+	}
+	if pw.started && overdue > pw.lastOverdue {
+		pw.notify(&Alert{
+			Kind:   AlertOverdueIncrease,
+			Prover: pw.prover.Hex(),
+			Message: fmt.Sprintf("overdue count of prover %s increased from %d to %d",
+				pw.prover.Hex(), pw.lastOverdue, overdue),
+		})
+	}
+	pw.lastOverdue = overdue
+	pw.started = true
+	log.Printf("prover %s: %d pending, %d overdue", pw.prover.Hex(), len(pending), overdue)
+	return nil
+}
+
+func (pw *proverWatcher) notify(a *Alert) {
+	for _, n := range pw.notifiers {
+		if err := n.Notify(a); err != nil {
+			log.Printf("notify err: %s", err)
+		}
+	}
+}
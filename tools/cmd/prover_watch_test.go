@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakeProverViewer struct {
+	pending []bindings.IMarketViewerProverPendingItem
+	overdue uint64
+}
+
+func (v *fakeProverViewer) GetProverPendingRequests(opts *bind.CallOpts, prover common.Address) ([]bindings.IMarketViewerProverPendingItem, error) {
+	return v.pending, nil
+}
+
+func (v *fakeProverViewer) GetProverOverdueCount(opts *bind.CallOpts, prover common.Address) (uint64, error) {
+	return v.overdue, nil
+}
+
+type recordNotifier struct {
+	alerts []*Alert
+}
+
+func (n *recordNotifier) Notify(a *Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func (n *recordNotifier) kinds() map[string]int {
+	kinds := map[string]int{}
+	for _, a := range n.alerts {
+		kinds[a.Kind]++
+	}
+	return kinds
+}
+
+func newTestWatcher(v *fakeProverViewer, n Notifier) *proverWatcher {
+	return &proverWatcher{
+		prover:       common.HexToAddress("0x1"),
+		marketViewer: v,
+		alertBefore:  1800,
+		notifiers:    []Notifier{n},
+		alerted:      make(map[[32]byte]bool),
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := &webhookNotifier{url: srv.URL, client: srv.Client()}
+	a := &Alert{Kind: AlertNearDeadline, Prover: "0x01", ReqId: "0x02", Deadline: 100, Message: "soon"}
+	if err := n.Notify(a); err != nil {
+		t.Fatal(err)
+	}
+	if got != *a {
+		t.Errorf("webhook got %+v, want %+v", got, *a)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	n = &webhookNotifier{url: failing.URL, client: failing.Client()}
+	if err := n.Notify(a); err == nil {
+		t.Error("Notify succeeded on a 500 response")
+	}
+}
+
+func TestPollAlertsNearDeadlineOnce(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	v := &fakeProverViewer{pending: []bindings.IMarketViewerProverPendingItem{
+		{Reqid: [32]byte{1}, Deadline: now + 600},  // within alert_before
+		{Reqid: [32]byte{2}, Deadline: now + 7200}, // not yet
+		{Reqid: [32]byte{3}, Deadline: now - 60},   // already overdue
+	}}
+	n := &recordNotifier{}
+	pw := newTestWatcher(v, n)
+	for i := 0; i < 3; i++ {
+		if err := pw.poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(n.alerts) != 1 || n.alerts[0].ReqId != common.Hash([32]byte{1}).Hex() {
+		t.Fatalf("alerts %+v, want one for req 1", n.alerts)
+	}
+
+	// a request that is proved and won again alerts again
+	v.pending = v.pending[1:]
+	if err := pw.poll(); err != nil {
+		t.Fatal(err)
+	}
+	v.pending = append(v.pending, bindings.IMarketViewerProverPendingItem{Reqid: [32]byte{1}, Deadline: now + 600})
+	if err := pw.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := n.kinds(); kinds[AlertNearDeadline] != 2 {
+		t.Errorf("alerts %+v, want 2 near deadline", n.alerts)
+	}
+}
+
+func TestPollAlertsOverdueIncrease(t *testing.T) {
+	v := &fakeProverViewer{overdue: 3}
+	n := &recordNotifier{}
+	pw := newTestWatcher(v, n)
+	// the count at start is only the baseline
+	for _, overdue := range []uint64{3, 3, 4, 4, 2, 3} {
+		v.overdue = overdue
+		if err := pw.poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var increases []string
+	for _, a := range n.alerts {
+		if a.Kind == AlertOverdueIncrease {
+			increases = append(increases, a.Message)
+		}
+	}
+	if len(increases) != 2 {
+		t.Fatalf("overdue alerts %v, want 3->4 and 2->3", increases)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/big"
+	"time"
 	"tools/bindings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -14,7 +16,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type RefundConfig struct {
@@ -22,13 +23,40 @@ type RefundConfig struct {
 }
 
 const (
-	FlagAll = "all"
+	FlagAll       = "all"
+	FlagBatchSize = "batch-size"
+	FlagDryRun    = "dry-run"
 )
 
 var (
-	all bool
+	all       bool
+	batchSize int
+	dryRun    bool
 )
 
+// request status values of BrevisMarket, in declaration order of the
+// IBrevisMarket.ReqStatus enum that getRequest and requests return as uint8
+const (
+	ReqStatusPending uint8 = iota
+	ReqStatusFulfilled
+	ReqStatusRefunded
+	ReqStatusSlashed
+)
+
+func reqStatusName(status uint8) string {
+	switch status {
+	case ReqStatusPending:
+		return "pending"
+	case ReqStatusFulfilled:
+		return "fulfilled"
+	case ReqStatusRefunded:
+		return "refunded"
+	case ReqStatusSlashed:
+		return "slashed"
+	}
+	return fmt.Sprintf("status(%d)", status)
+}
+
 func RefundCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "refund",
@@ -38,8 +66,11 @@ func RefundCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(cmd.Flags(), "refund", RefundConfig{})
 	cmd.Flags().BoolVar(&all, FlagAll, false, "indicates whether to refund all refundable requests under my account")
-	cmd.MarkFlagRequired(FlagConfig)
+	cmd.Flags().IntVar(&batchSize, FlagBatchSize, 50, "max reqIds per BatchRefund tx")
+	cmd.Flags().BoolVar(&dryRun, FlagDryRun, false, "only preview the refund without sending tx")
+	cmd.AddCommand(RefundWatchCmd())
 	return cmd
 }
 
@@ -48,13 +79,12 @@ func init() {
 }
 
 func refund() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -63,23 +93,26 @@ func refund() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
 	auth, sender, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "CreateTransactOpts")
 	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
 	chkErr(err, "NewBrevisMarket")
 
+	marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+
 	var toRefundReqIds [][32]byte
 	if !all {
 		var refund RefundConfig
-		err = viper.UnmarshalKey("refund", &refund)
-		chkErr(err, "UnmarshalKey")
+		err = unmarshalConfig("refund", &refund)
+		chkErr(err, "unmarshalConfig")
 		for _, reqId := range refund.ReqIds {
 			toRefundReqIds = append(toRefundReqIds, common.HexToHash(reqId))
 		}
 	} else {
-		marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
-		chkErr(err, "NewMarketViewer")
 		toRefundReqIds, err = marketViewer.GetSenderRefundableRequests(nil, sender)
 		chkErr(err, "GetSenderRefundableRequests")
 	}
@@ -87,15 +120,107 @@ func refund() error {
 	if len(toRefundReqIds) == 0 {
 		log.Fatalf("no refundable requests")
 	}
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size should be positive")
+	}
+
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	toRefundReqIds, expected, err := previewRefund(brevisMarket, marketViewer, feeToken, toRefundReqIds)
+	chkErr(err, "previewRefund")
+	if len(toRefundReqIds) == 0 {
+		log.Fatalf("no refundable requests")
+	}
+	log.Printf("%d requests to refund in %d tx, expected refund %s",
+		len(toRefundReqIds), (len(toRefundReqIds)+batchSize-1)/batchSize, feeToken.fmt(expected))
+	if dryRun {
+		return nil
+	}
 
-	tx, err := brevisMarket.BatchRefund(auth, toRefundReqIds)
+	refunded, err := batchRefund(ec, brevisMarket, auth, toRefundReqIds, batchSize)
+	log.Printf("total refunded: %s", feeToken.fmt(refunded))
 	checkBrevisCustomError(err, "BatchRefund", bindings.IBrevisMarketABI)
-	log.Printf("BatchRefund tx: %s", tx.Hash())
-	receipt, err := bind.WaitMined(context.Background(), ec, tx)
-	chkErr(err, "Waitmined")
-	if receipt.Status != types.ReceiptStatusSuccessful {
-		log.Fatalln("BatchRefund tx status is not success")
-	}
 
 	return nil
 }
+
+// previewRefund prints status, max fee and expected refund of each request,
+// and drops the ones that cannot be refunded now with the reason
+func previewRefund(brevisMarket *bindings.BrevisMarket, marketViewer *bindings.MarketViewer, feeToken *token, reqids [][32]byte) ([][32]byte, *big.Int, error) {
+	views, err := marketViewer.BatchGetRequests(nil, reqids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BatchGetRequests: %w", err)
+	}
+	bidders, err := marketViewer.BatchGetBidders(nil, reqids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BatchGetBidders: %w", err)
+	}
+	biddingPhaseDuration, err := brevisMarket.BiddingPhaseDuration(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BiddingPhaseDuration: %w", err)
+	}
+	revealPhaseDuration, err := brevisMarket.RevealPhaseDuration(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("RevealPhaseDuration: %w", err)
+	}
+
+	now := uint64(time.Now().Unix())
+	expected := big.NewInt(0)
+	var refundable [][32]byte
+	for i, v := range views {
+		reqid := common.Hash(reqids[i]).Hex()
+		reason := ""
+		revealEnd := v.Timestamp + biddingPhaseDuration + revealPhaseDuration
+		switch {
+		case v.Timestamp == 0:
+			reason = "request not found"
+		case v.Status != ReqStatusPending:
+			reason = "request is " + reqStatusName(v.Status)
+		case now <= revealEnd:
+			reason = fmt.Sprintf("reveal phase ends in %s", time.Duration(revealEnd-now)*time.Second)
+		case now <= v.Deadline && bidders[i].Winner != ZeroAddr:
+			reason = fmt.Sprintf("winner %s still has %s to prove", bidders[i].Winner.Hex(), time.Duration(v.Deadline-now)*time.Second)
+		}
+		if reason != "" {
+			log.Printf("skip %s: %s", reqid, reason)
+			continue
+		}
+		log.Printf("%s: status %s, max fee %s, expected refund %s", reqid, reqStatusName(v.Status), feeToken.fmt(v.MaxFee), feeToken.fmt(v.MaxFee))
+		expected.Add(expected, v.MaxFee)
+		refundable = append(refundable, reqids[i])
+	}
+	return refundable, expected, nil
+}
+
+// batchRefund sends BatchRefund for reqids in chunks of at most batchSize and
+// returns the total amount refunded according to the Refunded event logs
+func batchRefund(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, auth *bind.TransactOpts, reqids [][32]byte, batchSize int) (*big.Int, error) {
+	total := big.NewInt(0)
+	for start := 0; start < len(reqids); start += batchSize {
+		end := start + batchSize
+		if end > len(reqids) {
+			end = len(reqids)
+		}
+		tx, err := brevisMarket.BatchRefund(auth, reqids[start:end])
+		if err != nil {
+			// keep the rpc error as is so the revert reason can be decoded
+			return total, err
+		}
+		log.Printf("BatchRefund tx (%d reqs): %s", end-start, tx.Hash())
+		receipt, err := bind.WaitMined(context.Background(), ec, tx)
+		if err != nil {
+			return total, fmt.Errorf("WaitMined: %w", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return total, fmt.Errorf("BatchRefund tx %s status is not success", tx.Hash())
+		}
+		for _, l := range receipt.Logs {
+			ev, err := brevisMarket.ParseRefunded(*l)
+			if err != nil {
+				continue
+			}
+			total.Add(total, ev.Amount)
+		}
+	}
+	return total, nil
+}
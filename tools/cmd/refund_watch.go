@@ -0,0 +1,131 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+type RefundWatchConfig struct {
+	Interval  uint64             `mapstructure:"interval"`
+	BatchSize int                `mapstructure:"batch_size"`
+	Accounts  []*RefundWatchAcct `mapstructure:"account"`
+}
+
+type RefundWatchAcct struct {
+	Keystore   string `mapstructure:"keystore"`
+	Passphrase string `mapstructure:"passphrase"`
+}
+
+// refundWatcher tracks one sender account and how much it has recovered so far
+type refundWatcher struct {
+	auth      *bind.TransactOpts
+	sender    common.Address
+	recovered *big.Int
+	feeToken  *token
+}
+
+func RefundWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "periodically refund all refundable requests of one or more sender accounts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return refundWatch()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(cmd.Flags(), "refund_watch", RefundWatchConfig{})
+	return cmd
+}
+
+func refundWatch() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	var w RefundWatchConfig
+	err = unmarshalConfig("refund_watch", &w)
+	chkErr(err, "unmarshalConfig")
+	if w.Interval == 0 {
+		w.Interval = 600
+	}
+	if w.BatchSize <= 0 {
+		w.BatchSize = 50
+	}
+	if len(w.Accounts) == 0 {
+		w.Accounts = []*RefundWatchAcct{{Keystore: c.Keystore, Passphrase: c.Passphrase}}
+	}
+
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+
+	var watchers []*refundWatcher
+	for i, a := range w.Accounts {
+		auth, sender, err := CreateTransactOpts(a.Keystore, a.Passphrase, chid)
+		chkErr(err, fmt.Sprintf("account %d: CreateTransactOpts", i+1))
+		watchers = append(watchers, &refundWatcher{auth: auth, sender: sender, recovered: big.NewInt(0), feeToken: feeToken})
+		log.Printf("watching sender %s", sender.Hex())
+	}
+
+	ticker := time.NewTicker(time.Duration(w.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		for _, rw := range watchers {
+			rw.poll(ec, brevisMarket, marketViewer, w.BatchSize)
+		}
+		<-ticker.C
+	}
+}
+
+// poll refunds whatever is refundable now, errors are logged and retried on the next tick
+func (rw *refundWatcher) poll(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, marketViewer *bindings.MarketViewer, batchSize int) {
+	overdue, err := marketViewer.GetSenderOverdueRequests(nil, rw.sender)
+	if err != nil {
+		log.Printf("sender %s: GetSenderOverdueRequests err: %s", rw.sender.Hex(), err)
+		return
+	}
+	refundable, err := marketViewer.GetSenderRefundableRequests(nil, rw.sender)
+	if err != nil {
+		log.Printf("sender %s: GetSenderRefundableRequests err: %s", rw.sender.Hex(), err)
+		return
+	}
+	log.Printf("sender %s: %d overdue, %d refundable", rw.sender.Hex(), len(overdue), len(refundable))
+	if len(refundable) == 0 {
+		return
+	}
+
+	amount, err := batchRefund(ec, brevisMarket, rw.auth, refundable, batchSize)
+	rw.recovered.Add(rw.recovered, amount)
+	if err != nil {
+		log.Printf("sender %s: refund err: %s", rw.sender.Hex(), err)
+	}
+	log.Printf("sender %s: refunded %s this round, %s in total", rw.sender.Hex(), rw.feeToken.fmt(amount), rw.feeToken.fmt(rw.recovered))
+}
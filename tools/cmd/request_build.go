@@ -0,0 +1,246 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagElf          = "elf"
+	FlagInput        = "input"
+	FlagPublicValues = "public-values"
+	FlagVk           = "vk"
+	FlagImgUrl       = "img-url"
+	FlagInputUrl     = "input-url"
+	FlagNonce        = "nonce"
+	FlagMaxFee       = "max-fee"
+	FlagMinStake     = "min-stake"
+	FlagDeadline     = "deadline"
+	FlagVersion      = "version"
+	FlagInlineMax    = "inline-max"
+	FlagVerifyUrls   = "verify-urls"
+)
+
+var (
+	elfFile          string
+	inputFile        string
+	publicValuesFile string
+	buildVk          string
+	buildImgUrl      string
+	buildInputUrl    string
+	buildNonce       uint64
+	buildMaxFee      string
+	buildMinStake    string
+	buildDeadline    string
+	buildVersion     uint32
+	inlineMax        int
+	verifyUrls       bool
+)
+
+// bn254 scalar field modulus, pico vk is a field element
+var bn254ScalarField, _ = big.NewInt(0).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+func RequestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "request",
+		Short: "helpers for preparing proof requests",
+	}
+	cmd.AddCommand(RequestBuildCmd())
+	cmd.AddCommand(RequestQuoteCmd())
+	return cmd
+}
+
+func RequestBuildCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "build a [[request]] entry from local pico artifacts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return buildRequest()
+		},
+	}
+	cmd.Flags().StringVar(&elfFile, FlagElf, "", "local pico ELF file")
+	cmd.Flags().StringVar(&inputFile, FlagInput, "", "local input file, raw bytes or 0x-prefixed hex")
+	cmd.Flags().StringVar(&publicValuesFile, FlagPublicValues, "", "expected public values file, raw bytes or 0x-prefixed hex")
+	cmd.Flags().StringVar(&buildVk, FlagVk, "", "verification key (app id) of the ELF")
+	cmd.Flags().StringVar(&buildImgUrl, FlagImgUrl, "", "url hosting the ELF")
+	cmd.Flags().StringVar(&buildInputUrl, FlagInputUrl, "", "url hosting the input, required if input is not inlined")
+	cmd.Flags().Uint64Var(&buildNonce, FlagNonce, 0, "request nonce, defaults to current unix time")
+	cmd.Flags().StringVar(&buildMaxFee, FlagMaxFee, "", "max fee, eg. \"10 BREV\" in fee token units or raw wei")
+	cmd.Flags().StringVar(&buildMinStake, FlagMinStake, "", "min prover stake, eg. \"1000 BREV\" in staking token units or raw wei")
+	cmd.Flags().StringVar(&buildDeadline, FlagDeadline, "24h", "unix timestamp or duration from now")
+	cmd.Flags().Uint32Var(&buildVersion, FlagVersion, 0, "pico verifier version")
+	cmd.Flags().IntVar(&inlineMax, FlagInlineMax, 0, "inline input as input_data if it is at most this many bytes, 0 disables inlining")
+	cmd.Flags().BoolVar(&verifyUrls, FlagVerifyUrls, true, "download img_url/input_url and compare with local files")
+	cmd.MarkFlagRequired(FlagElf)
+	cmd.MarkFlagRequired(FlagInput)
+	cmd.MarkFlagRequired(FlagPublicValues)
+	cmd.MarkFlagRequired(FlagVk)
+	cmd.MarkFlagRequired(FlagImgUrl)
+	cmd.MarkFlagRequired(FlagMaxFee)
+	cmd.MarkFlagRequired(FlagMinStake)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(RequestCmd())
+}
+
+func buildRequest() error {
+	elf, err := os.ReadFile(elfFile)
+	chkErr(err, "read elf")
+	input, err := readBinaryOrHexFile(inputFile)
+	chkErr(err, "read input")
+	publicValues, err := readBinaryOrHexFile(publicValuesFile)
+	chkErr(err, "read public values")
+
+	vk, err := parseVk(buildVk)
+	if err != nil {
+		return err
+	}
+	if err = checkAmount(buildMaxFee); err != nil {
+		return fmt.Errorf("max_fee: %w", err)
+	}
+	if err = checkAmount(buildMinStake); err != nil {
+		return fmt.Errorf("min_stake: %w", err)
+	}
+	if _, err = parseDeadline(buildDeadline, time.Now()); err != nil {
+		return err
+	}
+
+	inputData := "0x"
+	if inlineMax > 0 && len(input) <= inlineMax {
+		inputData = hexutil.Encode(input)
+	} else if buildInputUrl == "" {
+		return fmt.Errorf("input is %d bytes and not inlined, please provide --%s", len(input), FlagInputUrl)
+	}
+
+	if verifyUrls {
+		err = verifyUrlContent(buildImgUrl, elf)
+		if err != nil {
+			return fmt.Errorf("img_url: %w", err)
+		}
+		if buildInputUrl != "" {
+			err = verifyUrlContent(buildInputUrl, input)
+			if err != nil {
+				return fmt.Errorf("input_url: %w", err)
+			}
+		}
+	}
+
+	nonce := buildNonce
+	if nonce == 0 {
+		nonce = uint64(time.Now().Unix())
+	}
+	deadline := buildDeadline
+	if _, err = strconv.ParseUint(deadline, 10, 64); err != nil {
+		deadline = fmt.Sprintf("%q", deadline)
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "[[request]]")
+	fmt.Fprintf(&b, "nonce=%d\n", nonce)
+	fmt.Fprintf(&b, "vk=%q\n", vk.Hex())
+	fmt.Fprintf(&b, "public_value_digest=%q\n", PublicValuesDigest(publicValues).Hex())
+	fmt.Fprintf(&b, "img_url=%q\n", buildImgUrl)
+	fmt.Fprintf(&b, "input_url=%q\n", buildInputUrl)
+	fmt.Fprintf(&b, "input_data=%q\n", inputData)
+	fmt.Fprintf(&b, "max_fee=%q\n", buildMaxFee)
+	fmt.Fprintf(&b, "min_stake=%q\n", buildMinStake)
+	fmt.Fprintf(&b, "deadline=%s\n", deadline)
+	fmt.Fprintf(&b, "version=%d\n", buildVersion)
+	fmt.Print(b.String())
+
+	return nil
+}
+
+// PublicValuesDigest is sha256 of the public values with the top 3 bits
+// cleared, so that it fits in the bn254 scalar field
+func PublicValuesDigest(publicValues []byte) common.Hash {
+	digest := sha256.Sum256(publicValues)
+	digest[0] &= 0x1f
+	return digest
+}
+
+func parseVk(vk string) (common.Hash, error) {
+	b, err := hexutil.Decode(vk)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("vk is not valid hex: %w", err)
+	}
+	if len(b) != 32 {
+		return common.Hash{}, fmt.Errorf("vk should be 32 bytes, got %d", len(b))
+	}
+	if big.NewInt(0).SetBytes(b).Cmp(bn254ScalarField) >= 0 {
+		return common.Hash{}, fmt.Errorf("vk %s is not a bn254 field element", vk)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// readBinaryOrHexFile returns the file content, decoding it first if it is
+// 0x-prefixed hex text
+func readBinaryOrHexFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(b)
+	if bytes.HasPrefix(trimmed, []byte("0x")) {
+		if decoded, err := hexutil.Decode(string(trimmed)); err == nil {
+			return decoded, nil
+		}
+	}
+	return b, nil
+}
+
+// fetchers download url content by scheme, urls with other schemes are not verified
+var fetchers = map[string]func(url string) ([]byte, error){
+	"http":  httpFetch,
+	"https": httpFetch,
+}
+
+func httpFetch(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func verifyUrlContent(url string, local []byte) error {
+	scheme, _, found := strings.Cut(url, "://")
+	fetch, ok := fetchers[strings.ToLower(scheme)]
+	if !found || !ok {
+		log.Printf("no fetcher for %s, skip content check", url)
+		return nil
+	}
+	remote, err := fetch(url)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", url, err)
+	}
+	localHash, remoteHash := sha256.Sum256(local), sha256.Sum256(remote)
+	if localHash != remoteHash {
+		return fmt.Errorf("content mismatch, local sha256 %x, remote sha256 %x", localHash, remoteHash)
+	}
+	log.Printf("%s matches local file, sha256 %x", url, localHash)
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strconv"
+	"strings"
 	"time"
 	"tools/bindings"
 
@@ -16,7 +18,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type Request struct {
@@ -28,7 +29,7 @@ type Request struct {
 	InputData          string `mapstructure:"input_data"`
 	MaxFee             string `mapstructure:"max_fee"`
 	MinStake           string `mapstructure:"min_stake"`
-	Deadline           uint64 `mapstructure:"deadline"`
+	Deadline           string `mapstructure:"deadline"`
 	Version            uint32 `mapstructure:"version"`
 }
 
@@ -43,7 +44,7 @@ func RequestProofCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
-	cmd.MarkFlagRequired(FlagConfig)
+	addConfigFlags(cmd.Flags(), "request", Request{})
 	return cmd
 }
 
@@ -52,13 +53,12 @@ func init() {
 }
 
 func requestProof() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -67,43 +67,44 @@ func requestProof() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
 	var reqs Requests
-	err = viper.UnmarshalKey("request", &reqs)
-	chkErr(err, "UnmarshalKey")
+	err = unmarshalConfig("request", &reqs)
+	chkErr(err, "unmarshalConfig")
 	if len(reqs) == 0 {
 		return fmt.Errorf("should provide at least one request")
 	}
 
-	for i, r := range reqs {
-		if (r.InputData == "0x" || r.InputData == "") && r.InputUrl == "" {
-			return fmt.Errorf("req %d: should provide either input_data or input_url", i+1)
-		}
-
-		_, success := big.NewInt(0).SetString(r.MaxFee, 0)
-		if !success {
-			return fmt.Errorf("req %d: max_fee is not valid", i+1)
-		}
-		_, success = big.NewInt(0).SetString(r.MinStake, 0)
-		if !success {
-			return fmt.Errorf("req %d: min_stake is not valid", i+1)
-		}
-		if r.Deadline <= uint64(time.Now().Unix()) {
-			return fmt.Errorf("req %d: deadline should be a future time", i+1)
-		}
-	}
-
 	auth, _, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "CreateTransactOpts")
-	stakingToken, err := bindings.NewIERC20(common.HexToAddress(c.StakingTokenAddr), ec)
-	chkErr(err, "NewIERC20")
 	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
 	chkErr(err, "NewBrevisMarket")
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	stakeToken, err := getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
+	feeERC20, err := bindings.NewIERC20(feeToken.Addr, ec)
+	chkErr(err, "NewIERC20")
+
+	params, err := getMarketParams(brevisMarket)
+	chkErr(err, "getMarketParams")
+
+	now := time.Now()
+	deadlines := make([]uint64, len(reqs))
+	for i, r := range reqs {
+		deadlines[i], err = validateRequest(r, params, brevisMarket, feeToken, stakeToken, now)
+		if err != nil {
+			return fmt.Errorf("req %d: %s", i+1, err)
+		}
+	}
 
 	for i, r := range reqs {
-		feeInt, _ := big.NewInt(0).SetString(r.MaxFee, 0)
-		minStakeInt, _ := big.NewInt(0).SetString(r.MinStake, 0)
-		tx, err := stakingToken.Approve(auth, common.HexToAddress(c.BrevisMarketAddr), feeInt)
+		feeInt, _ := feeToken.parse(r.MaxFee)
+		minStakeInt, _ := stakeToken.parse(r.MinStake)
+		log.Printf("req %d: max fee %s, min stake %s", i+1, feeToken.fmt(feeInt), stakeToken.fmt(minStakeInt))
+		tx, err := feeERC20.Approve(auth, common.HexToAddress(c.BrevisMarketAddr), feeInt)
 		chkErr(err, fmt.Sprintf("req %d: Approve", i+1))
 		log.Printf("req %d: approve tx: %s", i+1, tx.Hash())
 		receipt, err := bind.WaitMined(context.Background(), ec, tx)
@@ -123,7 +124,7 @@ func requestProof() error {
 			Fee: bindings.IBrevisMarketFeeParams{
 				MaxFee:   feeInt,
 				MinStake: minStakeInt,
-				Deadline: r.Deadline,
+				Deadline: deadlines[i],
 			},
 			Version: r.Version,
 		})
@@ -142,3 +143,118 @@ func requestProof() error {
 
 	return nil
 }
+
+// marketParams holds the BrevisMarket settings that a proof request is checked against
+type marketParams struct {
+	MaxDeadlineDuration  uint64
+	MinMaxFee            *big.Int
+	MaxMaxFee            *big.Int
+	BiddingPhaseDuration uint64
+	RevealPhaseDuration  uint64
+}
+
+func getMarketParams(brevisMarket *bindings.BrevisMarket) (*marketParams, error) {
+	maxDeadlineDuration, err := brevisMarket.MAXDEADLINEDURATION(nil)
+	if err != nil {
+		return nil, fmt.Errorf("MAXDEADLINEDURATION: %w", err)
+	}
+	minMaxFee, err := brevisMarket.MinMaxFee(nil)
+	if err != nil {
+		return nil, fmt.Errorf("MinMaxFee: %w", err)
+	}
+	maxMaxFee, err := brevisMarket.MaxMaxFee(nil)
+	if err != nil {
+		return nil, fmt.Errorf("MaxMaxFee: %w", err)
+	}
+	biddingPhaseDuration, err := brevisMarket.BiddingPhaseDuration(nil)
+	if err != nil {
+		return nil, fmt.Errorf("BiddingPhaseDuration: %w", err)
+	}
+	revealPhaseDuration, err := brevisMarket.RevealPhaseDuration(nil)
+	if err != nil {
+		return nil, fmt.Errorf("RevealPhaseDuration: %w", err)
+	}
+	return &marketParams{
+		MaxDeadlineDuration:  maxDeadlineDuration.Uint64(),
+		MinMaxFee:            minMaxFee,
+		MaxMaxFee:            maxMaxFee,
+		BiddingPhaseDuration: biddingPhaseDuration,
+		RevealPhaseDuration:  revealPhaseDuration,
+	}, nil
+}
+
+// parseDeadline accepts either an absolute unix timestamp (e.g. "1767627013")
+// or a duration relative to now (e.g. "6h", "90m")
+func parseDeadline(deadline string, now time.Time) (uint64, error) {
+	deadline = strings.TrimSpace(deadline)
+	if deadline == "" {
+		return 0, fmt.Errorf("deadline is empty")
+	}
+	if ts, err := strconv.ParseUint(deadline, 10, 64); err == nil {
+		return ts, nil
+	}
+	d, err := time.ParseDuration(deadline)
+	if err != nil {
+		return 0, fmt.Errorf("deadline %q is neither a unix timestamp nor a duration", deadline)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("deadline duration %s should be positive", d)
+	}
+	return uint64(now.Add(d).Unix()), nil
+}
+
+// validateRequest checks the request against the onchain market params and
+// returns the resolved absolute deadline, max_fee is in feeToken and
+// min_stake in stakeToken
+func validateRequest(r *Request, params *marketParams, brevisMarket *bindings.BrevisMarket, feeToken, stakeToken *token, now time.Time) (uint64, error) {
+	if (r.InputData == "0x" || r.InputData == "") && r.InputUrl == "" {
+		return 0, fmt.Errorf("should provide either input_data or input_url")
+	}
+	if _, err := parseVk(r.Vk); err != nil {
+		return 0, err
+	}
+
+	maxFee, err := feeToken.parse(r.MaxFee)
+	if err != nil {
+		return 0, fmt.Errorf("max_fee: %w", err)
+	}
+	if maxFee.Cmp(params.MinMaxFee) < 0 {
+		return 0, fmt.Errorf("max_fee %s is lower than market minimum %s", feeToken.fmt(maxFee), feeToken.fmt(params.MinMaxFee))
+	}
+	if params.MaxMaxFee.Sign() > 0 && maxFee.Cmp(params.MaxMaxFee) > 0 {
+		return 0, fmt.Errorf("max_fee %s is higher than market maximum %s", feeToken.fmt(maxFee), feeToken.fmt(params.MaxMaxFee))
+	}
+	if _, err = stakeToken.parse(r.MinStake); err != nil {
+		return 0, fmt.Errorf("min_stake: %w", err)
+	}
+
+	deadline, err := parseDeadline(r.Deadline, now)
+	if err != nil {
+		return 0, err
+	}
+	nowTs := uint64(now.Unix())
+	if deadline <= nowTs {
+		return 0, fmt.Errorf("deadline should be a future time")
+	}
+	if deadline-nowTs > params.MaxDeadlineDuration {
+		return 0, fmt.Errorf("deadline is %s away, exceeds market maximum %s",
+			time.Duration(deadline-nowTs)*time.Second, time.Duration(params.MaxDeadlineDuration)*time.Second)
+	}
+	revealEnd := nowTs + params.BiddingPhaseDuration + params.RevealPhaseDuration
+	if deadline <= revealEnd {
+		return 0, fmt.Errorf("deadline leaves no time to prove: bidding + reveal phases end in %s",
+			time.Duration(params.BiddingPhaseDuration+params.RevealPhaseDuration)*time.Second)
+	}
+
+	verifier, err := brevisMarket.PicoVerifiers(nil, r.Version)
+	if err != nil {
+		return 0, fmt.Errorf("PicoVerifiers: %w", err)
+	}
+	if verifier == ZeroAddr {
+		return 0, fmt.Errorf("no pico verifier registered for version %d", r.Version)
+	}
+
+	log.Printf("deadline %s, %s left for proving after reveal phase",
+		time.Unix(int64(deadline), 0).UTC().Format(time.RFC3339), time.Duration(deadline-revealEnd)*time.Second)
+	return deadline, nil
+}
@@ -0,0 +1,446 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagLookback = "lookback"
+	FlagBlkDelta = "blk-delta"
+	FlagTarget   = "target"
+	FlagBySize   = "by-size"
+	FlagCache    = "cache"
+
+	FlagConfirmations = "confirmations"
+)
+
+var (
+	quoteVk        string
+	lookback       uint64
+	blkDelta       uint64
+	targetFill     float64
+	quoteBySize    bool
+	quoteCacheFile string
+	quoteConfirms  uint64
+)
+
+// QuoteCache persists scanned request outcomes so repeated quotes only scan new blocks
+type QuoteCache struct {
+	ChainID   uint64                  `json:"chain_id"`
+	Market    string                  `json:"market"`
+	FromBlock uint64                  `json:"from_block"`
+	ToBlock   uint64                  `json:"to_block"`
+	Requests  map[string]*QuoteRecord `json:"requests"`
+}
+
+type QuoteRecord struct {
+	Vk          string `json:"vk"`
+	InputSize   int    `json:"input_size"` // -1 if input is only provided by url
+	MaxFee      string `json:"max_fee"`
+	Deadline    uint64 `json:"deadline"`
+	Block       uint64 `json:"block"`
+	RequestedAt uint64 `json:"requested_at"`
+	Reveals     int    `json:"reveals"`
+	ActualFee   string `json:"actual_fee,omitempty"`
+	ProvedAt    uint64 `json:"proved_at,omitempty"`
+	Refunded    bool   `json:"refunded,omitempty"`
+}
+
+func RequestQuoteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quote",
+		Short: "estimate max_fee and deadline from historical market data",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return quoteRequest()
+		},
+	}
+	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	cmd.Flags().StringVar(&quoteVk, FlagVk, "", "only consider requests with this vk")
+	cmd.Flags().Uint64Var(&lookback, FlagLookback, 302400, "number of blocks to look back, default is about 7 days on base")
+	addBlkDeltaFlag(cmd.Flags())
+	cmd.Flags().Float64Var(&targetFill, FlagTarget, 0.9, "target fill probability, between 0 and 1")
+	cmd.Flags().BoolVar(&quoteBySize, FlagBySize, false, "group by inline input size instead of vk")
+	cmd.Flags().StringVar(&quoteCacheFile, FlagCache, "quote_cache.json", "local cache file, empty to disable")
+	cmd.Flags().Uint64Var(&quoteConfirms, FlagConfirmations, 64, "only scan blocks this deep, so the cache never keeps events of reorged out blocks")
+	return cmd
+}
+
+func quoteRequest() error {
+	if targetFill <= 0 || targetFill >= 1 {
+		return fmt.Errorf("target should be between 0 and 1")
+	}
+
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	marketViewer, err := bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	start := uint64(0)
+	if head > lookback {
+		start = head - lookback
+	}
+
+	cache := loadQuoteCache(quoteCacheFile, c.ChainID, common.HexToAddress(c.BrevisMarketAddr))
+	scanFrom := start
+	if cache.ToBlock > 0 && cache.FromBlock <= start && cache.ToBlock >= start {
+		scanFrom = cache.ToBlock + 1
+	} else {
+		cache.Requests = make(map[string]*QuoteRecord)
+		cache.FromBlock = start
+	}
+	if safe := head - min(head, quoteConfirms); scanFrom <= safe {
+		log.Printf("scanning blocks %d to %d", scanFrom, safe)
+		sc := scanner.NewScanner(ec, scanFrom, nil, scanner.Config{MaxBlkDelta: blkDelta, Confirmations: quoteConfirms})
+		err = sc.Scan(context.Background(), func(r *scanner.Range) error {
+			return scanQuoteRecords(ec, brevisMarket, cache, r)
+		})
+		chkErr(err, "scanQuoteRecords")
+		if cp := sc.Checkpoint(); cp != nil {
+			cache.ToBlock = cp.BlockNumber
+		}
+	}
+	if quoteCacheFile != "" {
+		err = saveQuoteCache(quoteCacheFile, cache)
+		chkErr(err, "saveQuoteCache")
+	}
+
+	biddingPhaseDuration, err := brevisMarket.BiddingPhaseDuration(nil)
+	chkErr(err, "BiddingPhaseDuration")
+	revealPhaseDuration, err := brevisMarket.RevealPhaseDuration(nil)
+	chkErr(err, "RevealPhaseDuration")
+	minDeadline := time.Duration(biddingPhaseDuration+revealPhaseDuration) * time.Second
+	// requests whose deadline passed before the last scanned block are settled
+	var scannedAt uint64
+	if cache.ToBlock > 0 {
+		header, err := ec.HeaderByNumber(context.Background(), big.NewInt(0).SetUint64(cache.ToBlock))
+		chkErr(err, "HeaderByNumber")
+		scannedAt = header.Time
+	}
+
+	feeToken, err := getFeeToken(ec, &c)
+	chkErr(err, "getFeeToken")
+	global, err := marketViewer.GetGlobalStatsComposite(nil)
+	chkErr(err, "GetGlobalStatsComposite")
+	fmt.Printf("network total: %d requests, %d fulfilled, %s fees\n",
+		global.Total.TotalRequests, global.Total.TotalFulfilled, feeToken.fmt(global.Total.TotalFees))
+	fmt.Printf("network recent (since %s): %d requests, %d fulfilled, %s fees\n\n",
+		time.Unix(int64(global.RecentStartAt), 0).UTC().Format(time.RFC3339),
+		global.Recent.TotalRequests, global.Recent.TotalFulfilled, feeToken.fmt(global.Recent.TotalFees))
+
+	groups := make(map[string][]*QuoteRecord)
+	for _, r := range cache.Requests {
+		if r.Block < start {
+			continue
+		}
+		if quoteVk != "" && !strings.EqualFold(r.Vk, common.HexToHash(quoteVk).Hex()) {
+			continue
+		}
+		key := r.Vk
+		if quoteBySize {
+			key = inputSizeBucket(r.InputSize)
+		}
+		groups[key] = append(groups[key], r)
+	}
+	if len(groups) == 0 {
+		log.Println("no matching requests in the scanned range")
+		return nil
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(groups[keys[i]]) > len(groups[keys[j]]) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	groupName := "VK"
+	if quoteBySize {
+		groupName = "INPUT SIZE"
+	}
+	fmt.Fprintf(w, "%s\tREQS\tFILLED\tREFUNDED\tAVG REVEALS\tFEE P50\tFEE P%d\tLATENCY P50\tLATENCY P%d\tREC MAX_FEE\tREC DEADLINE\n",
+		groupName, int(targetFill*100), int(targetFill*100))
+	for _, k := range keys {
+		q := summarizeQuote(groups[k], targetFill, minDeadline, scannedAt, feeToken)
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\t%.1f\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			k, q.Requests, q.Fulfilled, q.RefundedPct, q.AvgReveals, q.FeeP50, q.FeeTarget,
+			q.LatencyP50, q.LatencyTarget, q.RecMaxFee, q.RecDeadline)
+	}
+	w.Flush()
+
+	return nil
+}
+
+type quoteSummary struct {
+	Requests      int
+	Fulfilled     int
+	RefundedPct   float64
+	AvgReveals    float64
+	FeeP50        string
+	FeeTarget     string
+	LatencyP50    time.Duration
+	LatencyTarget time.Duration
+	RecMaxFee     string
+	RecDeadline   time.Duration
+}
+
+// minQuoteSamples is the fewest settled requests a recommended max_fee is based on
+const minQuoteSamples = 5
+
+// summarizeQuote recommends as max_fee the lowest historical max_fee at which
+// at least the target fraction of settled requests offering that much or more
+// were fulfilled, and the target quantile of fulfillment latency with 50%
+// headroom (but no less than the bidding and reveal phases) as deadline.
+// Requests neither fulfilled nor past their deadline at scannedAt are left out
+// of the fill rate as their outcome is unknown
+func summarizeQuote(records []*QuoteRecord, target float64, minDeadline time.Duration, scannedAt uint64, feeToken *token) *quoteSummary {
+	q := &quoteSummary{Requests: len(records), FeeP50: "-", FeeTarget: "-", RecMaxFee: "-"}
+	type settled struct {
+		maxFee *big.Int
+		filled bool
+	}
+	var fees []*big.Int
+	var latencies []time.Duration
+	var outcomes []settled
+	refunded, reveals := 0, 0
+	for _, r := range records {
+		reveals += r.Reveals
+		if r.Refunded {
+			refunded++
+		}
+		maxFee, ok := big.NewInt(0).SetString(r.MaxFee, 10)
+		if r.ActualFee == "" {
+			if ok && r.Deadline < scannedAt {
+				outcomes = append(outcomes, settled{maxFee, false})
+			}
+			continue
+		}
+		q.Fulfilled++
+		if ok {
+			outcomes = append(outcomes, settled{maxFee, true})
+		}
+		fee, _ := big.NewInt(0).SetString(r.ActualFee, 10)
+		fees = append(fees, fee)
+		if r.ProvedAt >= r.RequestedAt {
+			latencies = append(latencies, time.Duration(r.ProvedAt-r.RequestedAt)*time.Second)
+		}
+	}
+	q.RefundedPct = 100 * float64(refunded) / float64(len(records))
+	q.AvgReveals = float64(reveals) / float64(len(records))
+
+	if len(fees) > 0 {
+		sort.Slice(fees, func(i, j int) bool { return fees[i].Cmp(fees[j]) < 0 })
+		q.FeeP50 = feeToken.fmt(fees[quantileIndex(len(fees), 0.5)])
+		q.FeeTarget = feeToken.fmt(fees[quantileIndex(len(fees), target)])
+	}
+	// walk max_fee from the highest down, counting the requests offering at
+	// least that much, and keep the lowest one that still meets the target
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].maxFee.Cmp(outcomes[j].maxFee) > 0 })
+	offered, filled := 0, 0
+	for i, o := range outcomes {
+		offered++
+		if o.filled {
+			filled++
+		}
+		if i+1 < len(outcomes) && outcomes[i+1].maxFee.Cmp(o.maxFee) == 0 {
+			continue
+		}
+		if offered >= minQuoteSamples && float64(filled) >= target*float64(offered) {
+			q.RecMaxFee = feeToken.fmt(o.maxFee)
+		}
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		q.LatencyP50 = latencies[quantileIndex(len(latencies), 0.5)]
+		q.LatencyTarget = latencies[quantileIndex(len(latencies), target)]
+	}
+	q.RecDeadline = (q.LatencyTarget * 3 / 2).Round(time.Minute)
+	if q.RecDeadline < minDeadline {
+		q.RecDeadline = minDeadline
+	}
+	return q
+}
+
+func quantileIndex(n int, q float64) int {
+	idx := int(math.Ceil(q*float64(n))) - 1
+	if idx < 0 {
+		return 0
+	}
+	if idx >= n {
+		return n - 1
+	}
+	return idx
+}
+
+func inputSizeBucket(size int) string {
+	if size < 0 {
+		return "url"
+	}
+	bucket := 1
+	for bucket < size {
+		bucket *= 2
+	}
+	return fmt.Sprintf("<=%dB", bucket)
+}
+
+func scanQuoteRecords(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, cache *QuoteCache, r *scanner.Range) error {
+	blkTime := make(map[uint64]uint64)
+	getBlkTime := func(blk uint64) (uint64, error) {
+		if t, ok := blkTime[blk]; ok {
+			return t, nil
+		}
+		header, err := ec.HeaderByNumber(context.Background(), big.NewInt(0).SetUint64(blk))
+		if err != nil {
+			return 0, err
+		}
+		blkTime[blk] = header.Time
+		return header.Time, nil
+	}
+
+	err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketNewRequestIterator, error) {
+		return brevisMarket.FilterNewRequest(opts, nil)
+	}, func(it *bindings.BrevisMarketNewRequestIterator) error {
+		ev := it.Event
+		ts, err := getBlkTime(ev.Raw.BlockNumber)
+		if err != nil {
+			return fmt.Errorf("HeaderByNumber: %w", err)
+		}
+		size := len(ev.Req.InputData)
+		if size == 0 {
+			size = -1
+		}
+		cache.Requests[common.Hash(ev.Reqid).Hex()] = &QuoteRecord{
+			Vk:          common.Hash(ev.Req.Vk).Hex(),
+			InputSize:   size,
+			MaxFee:      ev.Req.Fee.MaxFee.String(),
+			Deadline:    ev.Req.Fee.Deadline,
+			Block:       ev.Raw.BlockNumber,
+			RequestedAt: ts,
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("NewRequest: %w", err)
+	}
+
+	// reveals are counted once all queries of the range succeeded, as the
+	// scanner retries a range that the rpc rejected
+	var revealed []string
+	err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketBidRevealedIterator, error) {
+		return brevisMarket.FilterBidRevealed(opts, nil, nil)
+	}, func(it *bindings.BrevisMarketBidRevealedIterator) error {
+		revealed = append(revealed, common.Hash(it.Event.Reqid).Hex())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("BidRevealed: %w", err)
+	}
+
+	err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProofSubmittedIterator, error) {
+		return brevisMarket.FilterProofSubmitted(opts, nil, nil)
+	}, func(it *bindings.BrevisMarketProofSubmittedIterator) error {
+		ev := it.Event
+		rec, ok := cache.Requests[common.Hash(ev.Reqid).Hex()]
+		if !ok {
+			return nil
+		}
+		ts, err := getBlkTime(ev.Raw.BlockNumber)
+		if err != nil {
+			return fmt.Errorf("HeaderByNumber: %w", err)
+		}
+		rec.ActualFee = ev.ActualFee.String()
+		rec.ProvedAt = ts
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ProofSubmitted: %w", err)
+	}
+
+	err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketRefundedIterator, error) {
+		return brevisMarket.FilterRefunded(opts, nil, nil)
+	}, func(it *bindings.BrevisMarketRefundedIterator) error {
+		if rec, ok := cache.Requests[common.Hash(it.Event.Reqid).Hex()]; ok {
+			rec.Refunded = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Refunded: %w", err)
+	}
+
+	for _, reqid := range revealed {
+		if rec, ok := cache.Requests[reqid]; ok {
+			rec.Reveals++
+		}
+	}
+	return nil
+}
+
+func loadQuoteCache(path string, chainId uint64, market common.Address) *QuoteCache {
+	empty := &QuoteCache{ChainID: chainId, Market: market.Hex(), Requests: make(map[string]*QuoteRecord)}
+	if path == "" {
+		return empty
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty
+	}
+	chkErr(err, "read cache")
+	var cache QuoteCache
+	if err = json.Unmarshal(b, &cache); err != nil {
+		log.Printf("ignore invalid cache %s: %s", path, err)
+		return empty
+	}
+	if cache.ChainID != chainId || cache.Market != market.Hex() || cache.Requests == nil {
+		log.Printf("cache %s is for a different market, ignore it", path)
+		return empty
+	}
+	return &cache
+}
+
+func saveQuoteCache(path string, cache *QuoteCache) error {
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeQuoteFillRate(t *testing.T) {
+	raw := &token{Symbol: "RAW", Decimals: 0}
+	var records []*QuoteRecord
+	add := func(maxFee string, n int, filled bool, deadline uint64) {
+		for i := 0; i < n; i++ {
+			r := &QuoteRecord{MaxFee: maxFee, Deadline: deadline, RequestedAt: 100}
+			if filled {
+				r.ActualFee, r.ProvedAt = "1", 160
+			}
+			records = append(records, r)
+		}
+	}
+	// below 20 most requests expire unfilled, from 20 up all are filled
+	add("10", 6, false, 500)
+	add("10", 4, true, 500)
+	add("20", 5, true, 500)
+	add("30", 5, true, 500)
+	// still open, so not counted against 5
+	add("5", 10, false, 2000)
+
+	q := summarizeQuote(records, 0.9, time.Minute, 1000, raw)
+	if q.RecMaxFee != raw.fmt(bigInt("20")) {
+		t.Errorf("rec max_fee %s, want 20", q.RecMaxFee)
+	}
+	// the fee fulfilled requests paid does not drive the recommendation
+	if q.FeeTarget != raw.fmt(bigInt("1")) {
+		t.Errorf("fee target %s, want 1", q.FeeTarget)
+	}
+	if q.Fulfilled != 14 || q.Requests != 30 {
+		t.Errorf("fulfilled %d of %d, want 14 of 30", q.Fulfilled, q.Requests)
+	}
+
+	// once the open requests expire unfilled, 10 and up meet a 0.5 target but 5 does not
+	q = summarizeQuote(records, 0.5, time.Minute, 3000, raw)
+	if q.RecMaxFee != raw.fmt(bigInt("10")) {
+		t.Errorf("rec max_fee %s, want 10", q.RecMaxFee)
+	}
+
+	// too few settled requests for a recommendation
+	q = summarizeQuote(records[:4], 0.5, time.Minute, 1000, raw)
+	if q.RecMaxFee != "-" {
+		t.Errorf("rec max_fee %s, want -", q.RecMaxFee)
+	}
+}
@@ -0,0 +1,573 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"tools/bindings"
+	"tools/scanner"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	rolesContract  string
+	rolesFromBlock uint64
+)
+
+// knownRoles are role names whose keccak256 hashes are shown by name
+var knownRoles = []string{"EPOCH_UPDATER_ROLE"}
+
+// accessContract is a contract with the AccessControl and Ownable functions
+type accessContract struct {
+	Name string
+	Addr common.Address
+	*bindings.AccessControl
+}
+
+// roleBook maps role hashes to names
+type roleBook map[[32]byte]string
+
+func newRoleBook(brevisMarket *bindings.BrevisMarket) roleBook {
+	b := make(roleBook)
+	for _, name := range knownRoles {
+		b[crypto.Keccak256Hash([]byte(name))] = name
+	}
+	// the deployed constant wins if it is not the hash of its name
+	if role, err := brevisMarket.EPOCHUPDATERROLE(nil); err == nil {
+		b[role] = "EPOCH_UPDATER_ROLE"
+	}
+	return b
+}
+
+func (b roleBook) name(role [32]byte) string {
+	if name, ok := b[role]; ok {
+		return name
+	}
+	return common.Hash(role).Hex()
+}
+
+// parseRole accepts a bytes32 hex or a role name, which is hashed like solidity keccak256("NAME")
+func parseRole(s string) [32]byte {
+	if strings.HasPrefix(s, "0x") && len(s) == 66 {
+		return common.HexToHash(s)
+	}
+	return crypto.Keccak256Hash([]byte(s))
+}
+
+func RolesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "roles",
+		Short: "list, grant and revoke AccessControl roles of the market and staking contracts",
+	}
+	addAccessFlags(cmd)
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "print owner, role admins and role members of both contracts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesList(cmd.Flags().Changed(FlagFromBlock))
+		},
+	}
+	list.Flags().Uint64Var(&rolesFromBlock, FlagFromBlock, 0, "find roles in RoleGranted events since this block, default to index.start_block or the contract deploy block")
+	addBlkDeltaFlag(list.Flags())
+	audit := &cobra.Command{
+		Use:   "audit",
+		Short: "print the history of role and ownership events of both contracts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesAudit()
+		},
+	}
+	audit.Flags().Uint64Var(&rolesFromBlock, FlagFromBlock, 0, "first block to scan, default to index.start_block or the contract deploy block")
+	addBlkDeltaFlag(audit.Flags())
+	grant := &cobra.Command{
+		Use:   "grant <role> <account>...",
+		Short: "grant a role, given by name (eg. EPOCH_UPDATER_ROLE) or bytes32 hex",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesUpdate("grantRole", args[0], args[1:])
+		},
+	}
+	revoke := &cobra.Command{
+		Use:   "revoke <role> <account>...",
+		Short: "revoke a role, given by name or bytes32 hex",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesUpdate("revokeRole", args[0], args[1:])
+		},
+	}
+	setAdmin := &cobra.Command{
+		Use:   "set-admin <role> <admin>",
+		Short: "set the account that can grant and revoke a role",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesSetAdmin(args[0], args[1])
+		},
+	}
+	for _, c := range []*cobra.Command{grant, revoke, setAdmin} {
+		c.Flags().StringVar(&rolesContract, FlagContract, "market", "market or staking")
+	}
+	cmd.AddCommand(list, audit, grant, revoke, setAdmin)
+	return cmd
+}
+
+func OwnershipCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ownership",
+		Short: "show and transfer ownership of the market and staking contracts",
+	}
+	addAccessFlags(cmd)
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "print owner and pending owner of both contracts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ownershipShow()
+		},
+	}
+	transfer := &cobra.Command{
+		Use:   "transfer <new_owner>",
+		Short: "start a two step ownership transfer, the new owner has to accept it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ownershipUpdate("startOwnershipTransfer", args[0])
+		},
+	}
+	accept := &cobra.Command{
+		Use:   "accept",
+		Short: "accept a pending ownership transfer, signed by the pending owner",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ownershipUpdate("acceptOwnership", "")
+		},
+	}
+	cancel := &cobra.Command{
+		Use:   "cancel",
+		Short: "cancel a pending ownership transfer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ownershipUpdate("cancelOwnershipTransfer", "")
+		},
+	}
+	for _, c := range []*cobra.Command{transfer, accept, cancel} {
+		c.Flags().StringVar(&rolesContract, FlagContract, "market", "market or staking")
+	}
+	cmd.AddCommand(show, transfer, accept, cancel)
+	return cmd
+}
+
+// addAccessFlags adds the flags the admin commands share
+func addAccessFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfirmFlags(cmd)
+	cmd.PersistentFlags().StringVar(&adminSafe, FlagSafe, "", "append the tx to this Safe Transaction Builder batch json instead of sending it")
+}
+
+func init() {
+	rootCmd.AddCommand(RolesCmd(), OwnershipCmd())
+}
+
+// dialAccess returns both contracts, or only the --contract one if one is true
+func dialAccess(one bool) (*ChainConfig, *ethclient.Client, *big.Int, []*accessContract, roleBook, error) {
+	c, ec, chid, brevisMarket, err := dialMarket()
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	var contracts []*accessContract
+	for _, name := range []string{"market", "staking"} {
+		if one && name != rolesContract {
+			continue
+		}
+		addr := common.HexToAddress(c.BrevisMarketAddr)
+		if name == "staking" {
+			addr = common.HexToAddress(c.StakingControllerAddr)
+		}
+		ac, err := bindings.NewAccessControl(addr, ec)
+		chkErr(err, "NewAccessControl")
+		contracts = append(contracts, &accessContract{Name: name, Addr: addr, AccessControl: ac})
+	}
+	if len(contracts) == 0 {
+		return nil, nil, nil, nil, nil, fmt.Errorf("invalid contract %q, should be market or staking", rolesContract)
+	}
+	return c, ec, chid, contracts, newRoleBook(brevisMarket), nil
+}
+
+func ownershipShow() error {
+	_, _, _, contracts, _, err := dialAccess(false)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTRACT\tADDRESS\tOWNER\tPENDING_OWNER")
+	for _, ac := range contracts {
+		owner, err := ac.Owner(nil)
+		chkErr(err, ac.Name+" Owner")
+		pending, err := ac.PendingOwner(nil)
+		chkErr(err, ac.Name+" PendingOwner")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ac.Name, ac.Addr.Hex(), owner.Hex(), fmtOptionalAddr(pending))
+	}
+	w.Flush()
+	return nil
+}
+
+func rolesList(fromChanged bool) error {
+	_, ec, _, contracts, book, err := dialAccess(false)
+	if err != nil {
+		return err
+	}
+	if !fromChanged {
+		rolesFromBlock = viper.GetUint64("index.start_block")
+		fromChanged = rolesFromBlock > 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTRACT\tROLE\tHASH\tADMIN\tMEMBERS")
+	for _, ac := range contracts {
+		owner, err := ac.Owner(nil)
+		chkErr(err, ac.Name+" Owner")
+		pending, err := ac.PendingOwner(nil)
+		chkErr(err, ac.Name+" PendingOwner")
+		fmt.Fprintf(w, "%s\towner\t\t\t%s\n", ac.Name, owner.Hex())
+		if pending != ZeroAddr {
+			fmt.Fprintf(w, "%s\tpending owner\t\t\t%s\n", ac.Name, pending.Hex())
+		}
+
+		roles := make(map[[32]byte]bool)
+		for role := range book {
+			roles[role] = true
+		}
+		from, scan := rolesFromBlock, true
+		if !fromChanged {
+			if from, err = deployBlock(ec, ac.Addr); err != nil {
+				log.Printf("WARNING: %s deploy block: %s. Only known roles (%s) are listed, set --%s or index.start_block to find all",
+					ac.Name, err, strings.Join(knownRoles, ", "), FlagFromBlock)
+				scan = false
+			}
+		}
+		if scan {
+			found, err := grantedRoles(ec, ac, from)
+			if err != nil {
+				return err
+			}
+			for _, role := range found {
+				roles[role] = true
+			}
+		}
+		var sorted [][32]byte
+		for role := range roles {
+			sorted = append(sorted, role)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return book.name(sorted[i]) < book.name(sorted[j]) })
+		for _, role := range sorted {
+			members, err := ac.RoleMembers(nil, role)
+			if err != nil {
+				// the contract may not implement AccessControl
+				log.Printf("%s RoleMembers: %s", ac.Name, err)
+				break
+			}
+			admin, err := ac.RoleAdmin(nil, role)
+			chkErr(err, ac.Name+" RoleAdmin")
+			if len(members) == 0 && admin == ZeroAddr && book[role] == "" {
+				continue
+			}
+			var list []string
+			for _, m := range members {
+				list = append(list, m.Hex())
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ac.Name, book.name(role), common.Hash(role).Hex(), fmtOptionalAddr(admin), strings.Join(list, ","))
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+// grantedRoles returns the roles that were ever granted on ac since from
+func grantedRoles(ec *ethclient.Client, ac *accessContract, from uint64) ([][32]byte, error) {
+	seen := make(map[[32]byte]bool)
+	var roles [][32]byte
+	sc := scanner.NewScanner(ec, from, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	err := sc.Scan(context.Background(), func(r *scanner.Range) error {
+		return scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.AccessControlRoleGrantedIterator, error) {
+			return ac.FilterRoleGranted(opts, nil, nil)
+		}, func(it *bindings.AccessControlRoleGrantedIterator) error {
+			if !seen[it.Event.Role] {
+				seen[it.Event.Role] = true
+				roles = append(roles, it.Event.Role)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s RoleGranted: %w", ac.Name, err)
+	}
+	return roles, nil
+}
+
+// deployBlock finds the block ac was deployed in by bisecting on its code,
+// which needs an rpc that serves past state
+func deployBlock(ec *ethclient.Client, addr common.Address) (uint64, error) {
+	head, err := ec.BlockNumber(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("BlockNumber: %w", err)
+	}
+	hasCode := func(blk uint64) (bool, error) {
+		code, err := ec.CodeAt(context.Background(), addr, new(big.Int).SetUint64(blk))
+		if err != nil {
+			return false, fmt.Errorf("CodeAt %d: %w", blk, err)
+		}
+		return len(code) > 0, nil
+	}
+	if ok, err := hasCode(head); err != nil || !ok {
+		return 0, fmt.Errorf("no code at %s: %v", addr.Hex(), err)
+	}
+	lo, hi := uint64(0), head
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := hasCode(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+var accessEvents = []string{
+	"RoleGranted", "RoleRevoked", "RoleAdminChanged",
+	"OwnershipTransferStarted", "OwnershipTransferCanceled", "OwnershipTransferred",
+}
+
+func rolesAudit() error {
+	_, ec, _, contracts, book, err := dialAccess(false)
+	if err != nil {
+		return err
+	}
+	if rolesFromBlock == 0 {
+		rolesFromBlock = viper.GetUint64("index.start_block")
+	}
+	if rolesFromBlock == 0 {
+		rolesFromBlock = math.MaxUint64
+		for _, ac := range contracts {
+			blk, err := deployBlock(ec, ac.Addr)
+			if err != nil {
+				log.Printf("%s deploy block: %s, scanning from block 0", ac.Name, err)
+				blk = 0
+			}
+			rolesFromBlock = min(rolesFromBlock, blk)
+		}
+	}
+	parsed, err := bindings.AccessControlMetaData.GetAbi()
+	chkErr(err, "AccessControl GetAbi")
+	var topics []common.Hash
+	for _, name := range accessEvents {
+		topics = append(topics, parsed.Events[name].ID)
+	}
+	names := make(map[common.Address]string)
+	var addrs []common.Address
+	for _, ac := range contracts {
+		names[ac.Addr] = ac.Name
+		addrs = append(addrs, ac.Addr)
+	}
+	log.Printf("scanning role and ownership events from block %d", rolesFromBlock)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCK\tTX\tCONTRACT\tEVENT\tDETAILS")
+	sc := scanner.NewScanner(ec, rolesFromBlock, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	err = sc.Scan(context.Background(), func(r *scanner.Range) error {
+		logs, err := ec.FilterLogs(r.Context, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(r.Start),
+			ToBlock:   new(big.Int).SetUint64(r.End),
+			Addresses: addrs,
+			Topics:    [][]common.Hash{topics},
+		})
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			ev, fields, err := decodeEvent(parsed, &l)
+			if err != nil || ev == nil {
+				log.Printf("decode event in tx %s: %v", l.TxHash.Hex(), err)
+				continue
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", l.BlockNumber, l.TxHash.Hex(), names[l.Address], ev.Name, fmtAccessFields(ev, fields, book))
+		}
+		return nil
+	})
+	w.Flush()
+	return err
+}
+
+// fmtAccessFields prints event fields with roles by name
+func fmtAccessFields(ev *abi.Event, fields map[string]interface{}, book roleBook) string {
+	var args []string
+	for _, arg := range ev.Inputs {
+		v := fmtAbiValue(fields[arg.Name])
+		if role, ok := fields[arg.Name].([32]byte); ok && arg.Name == "role" {
+			v = book.name(role)
+		}
+		args = append(args, fmt.Sprintf("%s=%s", arg.Name, v))
+	}
+	return strings.Join(args, " ")
+}
+
+func rolesUpdate(method, roleArg string, accountArgs []string) error {
+	c, ec, chid, contracts, book, err := dialAccess(true)
+	if err != nil {
+		return err
+	}
+	ac := contracts[0]
+	role := parseRole(roleArg)
+	var accounts []common.Address
+	var lines []string
+	for _, arg := range accountArgs {
+		if !common.IsHexAddress(arg) {
+			return fmt.Errorf("invalid account address %s", arg)
+		}
+		account := common.HexToAddress(arg)
+		has, err := ac.HasRole(nil, role, account)
+		chkErr(err, "HasRole")
+		if has && method == "grantRole" {
+			log.Printf("%s already has %s, skipped", account.Hex(), book.name(role))
+			continue
+		}
+		if !has && method == "revokeRole" {
+			log.Printf("%s does not have %s, skipped", account.Hex(), book.name(role))
+			continue
+		}
+		accounts = append(accounts, account)
+		lines = append(lines, "  "+account.Hex())
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+	sender, err := roleSender(ac, role)
+	if err != nil {
+		return err
+	}
+	verb := strings.TrimSuffix(method, "Role")
+	args := []interface{}{role, accounts[0]}
+	if len(accounts) > 1 {
+		method += "s"
+		args = []interface{}{role, accounts}
+	}
+	_, err = execAdminCall(ec, c, chid, &adminCall{
+		Contract:    ac.Addr,
+		ABI:         bindings.AccessControlABI,
+		Method:      method,
+		Args:        args,
+		Summary:     fmt.Sprintf("%s %s (%s) on %s %s to:\n%s", verb, book.name(role), common.Hash(role).Hex(), ac.Name, ac.Addr.Hex(), strings.Join(lines, "\n")),
+		Sender:      sender,
+		Destructive: verb == "revoke",
+	})
+	return err
+}
+
+func rolesSetAdmin(roleArg, adminArg string) error {
+	if !common.IsHexAddress(adminArg) {
+		return fmt.Errorf("invalid admin address %s", adminArg)
+	}
+	c, ec, chid, contracts, book, err := dialAccess(true)
+	if err != nil {
+		return err
+	}
+	ac := contracts[0]
+	role := parseRole(roleArg)
+	current, err := ac.RoleAdmin(nil, role)
+	chkErr(err, "RoleAdmin")
+	owner, err := ac.Owner(nil)
+	chkErr(err, "Owner")
+	_, err = execAdminCall(ec, c, chid, &adminCall{
+		Contract:    ac.Addr,
+		ABI:         bindings.AccessControlABI,
+		Method:      "setRoleAdmin",
+		Args:        []interface{}{role, common.HexToAddress(adminArg)},
+		Summary:     fmt.Sprintf("%s admin on %s: %s -> %s", book.name(role), ac.Name, fmtOptionalAddr(current), common.HexToAddress(adminArg).Hex()),
+		Sender:      owner,
+		Destructive: true,
+	})
+	return err
+}
+
+// roleSender is the role admin if set, else the owner
+func roleSender(ac *accessContract, role [32]byte) (common.Address, error) {
+	admin, err := ac.RoleAdmin(nil, role)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("RoleAdmin: %w", err)
+	}
+	if admin != ZeroAddr {
+		return admin, nil
+	}
+	owner, err := ac.Owner(nil)
+	if err != nil {
+		return ZeroAddr, fmt.Errorf("Owner: %w", err)
+	}
+	return owner, nil
+}
+
+func ownershipUpdate(method, newOwnerArg string) error {
+	c, ec, chid, contracts, _, err := dialAccess(true)
+	if err != nil {
+		return err
+	}
+	ac := contracts[0]
+	owner, err := ac.Owner(nil)
+	chkErr(err, "Owner")
+	pending, err := ac.PendingOwner(nil)
+	chkErr(err, "PendingOwner")
+
+	call := &adminCall{Contract: ac.Addr, ABI: bindings.AccessControlABI, Method: method, Sender: owner}
+	switch method {
+	case "startOwnershipTransfer":
+		if !common.IsHexAddress(newOwnerArg) {
+			return fmt.Errorf("invalid new owner address %s", newOwnerArg)
+		}
+		newOwner := common.HexToAddress(newOwnerArg)
+		call.Args = []interface{}{newOwner}
+		call.Summary = fmt.Sprintf("start transferring %s ownership from %s to %s, who then has to accept it", ac.Name, owner.Hex(), newOwner.Hex())
+		call.Destructive = true
+	case "acceptOwnership":
+		if pending == ZeroAddr {
+			return fmt.Errorf("%s has no pending ownership transfer", ac.Name)
+		}
+		call.Summary = fmt.Sprintf("accept %s ownership, from %s to %s", ac.Name, owner.Hex(), pending.Hex())
+		call.Sender = pending
+	case "cancelOwnershipTransfer":
+		if pending == ZeroAddr {
+			return fmt.Errorf("%s has no pending ownership transfer", ac.Name)
+		}
+		call.Summary = fmt.Sprintf("cancel %s ownership transfer to %s", ac.Name, pending.Hex())
+	}
+	receipt, err := execAdminCall(ec, c, chid, call)
+	if err != nil || receipt == nil {
+		return err
+	}
+	owner, err = ac.Owner(nil)
+	chkErr(err, "Owner")
+	pending, err = ac.PendingOwner(nil)
+	chkErr(err, "PendingOwner")
+	fmt.Printf("%s owner is now %s, pending owner %s\n", ac.Name, owner.Hex(), fmtOptionalAddr(pending))
+	return nil
+}
+
+func fmtOptionalAddr(addr common.Address) string {
+	if addr == ZeroAddr {
+		return "-"
+	}
+	return addr.Hex()
+}
@@ -16,6 +16,7 @@ type ChainConfig struct {
 	StakingTokenAddr      string `mapstructure:"staking_token_addr"`
 	StakingControllerAddr string `mapstructure:"staking_controller_addr"`
 	MarketViewerAddr      string `mapstructure:"market_viewer_addr"`
+	Network               string `mapstructure:"network"`
 
 	Keystore   string `mapstructure:"keystore"`
 	Passphrase string `mapstructure:"passphrase"`
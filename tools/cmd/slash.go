@@ -0,0 +1,241 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagReportOnly = "report-only"
+	FlagInterval   = "interval"
+)
+
+var (
+	reportOnly    bool
+	slashInterval uint64
+	slashLookback uint64
+)
+
+// slashWatcher keeps what is needed across polls of the slash watchdog
+type slashWatcher struct {
+	ec                *ethclient.Client
+	auth              *bind.TransactOpts // nil in report-only mode
+	brevisMarket      *bindings.BrevisMarket
+	marketViewer      *bindings.MarketViewer
+	stakingController *bindings.IStakingController
+	startBlk          uint64
+	scanner           *scanner.Scanner
+	slashes           map[slashKey]slashEvent // ProverSlashed events of the last --lookback blocks, a range may be scanned again
+	token             *token
+}
+
+type slashKey struct {
+	TxHash common.Hash
+	Index  uint
+}
+
+type slashEvent struct {
+	BlockNumber uint64
+	Amount      *big.Int
+}
+
+func SlashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slash",
+		Short: "slash provers that missed their deadline",
+	}
+	watch := &cobra.Command{
+		Use:   "watch",
+		Short: "find overdue requests within slash window and slash the winners",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return slashWatch()
+		},
+	}
+	watch.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	watch.Flags().BoolVar(&reportOnly, FlagReportOnly, false, "only report overdue requests and slashing events, do not send tx")
+	watch.Flags().Uint64Var(&slashInterval, FlagInterval, 60, "seconds between polls")
+	watch.Flags().Uint64Var(&slashLookback, FlagLookback, 43200, "blocks to look back for ProverSlashed events at start, and to keep them in the running totals")
+	addBlkDeltaFlag(watch.Flags())
+	cmd.AddCommand(watch)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(SlashCmd())
+}
+
+func slashWatch() error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+
+	ec, err := ethclient.Dial(c.ChainRpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+
+	sw := &slashWatcher{ec: ec, slashes: map[slashKey]slashEvent{}}
+	sw.token, err = getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
+	if !reportOnly {
+		sw.auth, _, err = CreateTransactOpts(c.Keystore, c.Passphrase, chid)
+		chkErr(err, "CreateTransactOpts")
+	}
+	sw.brevisMarket, err = bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	sw.marketViewer, err = bindings.NewMarketViewer(common.HexToAddress(c.MarketViewerAddr), ec)
+	chkErr(err, "NewMarketViewer")
+	sw.stakingController, err = bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	if head > slashLookback {
+		sw.startBlk = head - slashLookback
+	}
+	sw.scanner = scanner.NewScanner(ec, sw.startBlk, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	sw.scanner.OnReorg = sw.dropSlashes
+
+	ticker := time.NewTicker(time.Duration(slashInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err = sw.poll(); err != nil {
+			log.Printf("poll err: %s", err)
+		}
+		<-ticker.C
+	}
+}
+
+func (sw *slashWatcher) poll() error {
+	slashWindow, err := sw.brevisMarket.SlashWindow(nil)
+	if err != nil {
+		return fmt.Errorf("SlashWindow: %w", err)
+	}
+	provers, err := listProvers(sw.stakingController)
+	if err != nil {
+		return err
+	}
+
+	now := uint64(time.Now().Unix())
+	for _, prover := range provers {
+		overdue, err := sw.marketViewer.GetProverOverdueRequests(nil, prover)
+		if err != nil {
+			return fmt.Errorf("GetProverOverdueRequests: %w", err)
+		}
+		if len(overdue) == 0 {
+			continue
+		}
+		views, err := sw.marketViewer.BatchGetRequests(nil, overdue)
+		if err != nil {
+			return fmt.Errorf("BatchGetRequests: %w", err)
+		}
+		for _, v := range views {
+			reqid := common.Hash(v.Reqid).Hex()
+			windowEnd := v.Deadline + slashWindow.Uint64()
+			if now > windowEnd {
+				log.Printf("prover %s req %s: overdue since %s, slash window expired",
+					prover.Hex(), reqid, time.Duration(now-v.Deadline)*time.Second)
+				continue
+			}
+			log.Printf("prover %s req %s: overdue since %s, slash window ends in %s",
+				prover.Hex(), reqid, time.Duration(now-v.Deadline)*time.Second, time.Duration(windowEnd-now)*time.Second)
+			if sw.auth != nil {
+				sw.slash(v.Reqid)
+			}
+		}
+	}
+
+	return sw.scanSlashed()
+}
+
+// slash simulates Slash first and only sends it if the simulation succeeds
+func (sw *slashWatcher) slash(reqid [32]byte) {
+	simAuth := *sw.auth
+	simAuth.NoSend = true
+	if _, err := sw.brevisMarket.Slash(&simAuth, reqid); err != nil {
+		log.Printf("req %s: Slash simulation failed: %s", common.Hash(reqid).Hex(), err)
+		return
+	}
+	tx, err := sw.brevisMarket.Slash(sw.auth, reqid)
+	if err != nil {
+		log.Printf("req %s: Slash err: %s", common.Hash(reqid).Hex(), err)
+		return
+	}
+	log.Printf("req %s: Slash tx: %s", common.Hash(reqid).Hex(), tx.Hash())
+	receipt, err := bind.WaitMined(context.Background(), sw.ec, tx)
+	if err != nil {
+		log.Printf("req %s: WaitMined err: %s", common.Hash(reqid).Hex(), err)
+		return
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		log.Printf("req %s: Slash tx status is not success", common.Hash(reqid).Hex())
+	}
+}
+
+// scanSlashed logs ProverSlashed events since the last scan with running totals
+func (sw *slashWatcher) scanSlashed() error {
+	err := sw.scanner.Scan(context.Background(), func(r *scanner.Range) error {
+		return scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProverSlashedIterator, error) {
+			return sw.brevisMarket.FilterProverSlashed(opts, nil, nil)
+		}, func(it *bindings.BrevisMarketProverSlashedIterator) error {
+			ev := it.Event
+			key := slashKey{TxHash: ev.Raw.TxHash, Index: ev.Raw.Index}
+			if _, ok := sw.slashes[key]; ok {
+				return nil
+			}
+			sw.slashes[key] = slashEvent{BlockNumber: ev.Raw.BlockNumber, Amount: ev.SlashAmount}
+			log.Printf("ProverSlashed: prover %s req %s amount %s, block %d tx %s",
+				ev.Prover.Hex(), common.Hash(ev.Reqid).Hex(), sw.token.fmt(ev.SlashAmount), ev.Raw.BlockNumber, ev.Raw.TxHash.Hex())
+			return nil
+		})
+	})
+	// keep the totals over the last --lookback blocks, so a long running
+	// watchdog does not hold every slash it has ever seen
+	from := sw.startBlk
+	if cp := sw.scanner.Checkpoint(); cp != nil && cp.BlockNumber > slashLookback && cp.BlockNumber-slashLookback > from {
+		from = cp.BlockNumber - slashLookback
+	}
+	total := big.NewInt(0)
+	for key, ev := range sw.slashes {
+		if ev.BlockNumber < from {
+			delete(sw.slashes, key)
+			continue
+		}
+		total.Add(total, ev.Amount)
+	}
+	log.Printf("%d slashes totaling %s seen since block %d", len(sw.slashes), sw.token.fmt(total), from)
+	return err
+}
+
+// dropSlashes forgets the events after cp, the scanner finds them again if
+// they are still on the new chain
+func (sw *slashWatcher) dropSlashes(cp *scanner.Checkpoint) error {
+	for key, ev := range sw.slashes {
+		if ev.BlockNumber > cp.BlockNumber {
+			delete(sw.slashes, key)
+		}
+	}
+	return nil
+}
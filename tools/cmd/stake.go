@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/big"
 	"time"
 	"tools/bindings"
 
@@ -16,7 +15,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type StakeConfig struct {
@@ -33,7 +31,7 @@ func StakeCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
-	cmd.MarkFlagRequired(FlagConfig)
+	addConfigFlags(cmd.Flags(), "stake", StakeConfig{})
 	return cmd
 }
 
@@ -42,13 +40,12 @@ func init() {
 }
 
 func stake() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -57,10 +54,12 @@ func stake() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
 	var s StakeConfig
-	err = viper.UnmarshalKey("stake", &s)
-	chkErr(err, "UnmarshalKey")
+	err = unmarshalConfig("stake", &s)
+	chkErr(err, "unmarshalConfig")
 
 	auth, _, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "prover CreateTransactOpts")
@@ -70,14 +69,17 @@ func stake() error {
 	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
 	chkErr(err, "NewIStakingController")
 
-	stakeAmt, success := big.NewInt(0).SetString(s.StakeAmt, 0)
-	if !success {
-		log.Fatalln("stake_amt is not a valid number")
+	token, err := getStakingToken(ec, &c)
+	chkErr(err, "getStakingToken")
+	stakeAmt, err := token.parse(s.StakeAmt)
+	if err != nil {
+		log.Fatalf("stake_amt: %s", err)
 	}
 
 	if stakeAmt.Sign() == 0 {
 		log.Fatalln("stake_amt should be larger than 0")
 	}
+	log.Printf("staking %s to prover %s", token.fmt(stakeAmt), common.HexToAddress(s.Prover).Hex())
 
 	tx, err := stakingToken.Approve(auth, common.HexToAddress(c.StakingControllerAddr), stakeAmt)
 	chkErr(err, "Approve")
@@ -0,0 +1,474 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagStaker = "staker"
+)
+
+const secondsPerYear = 365 * 24 * 3600
+
+var (
+	rewardsProver string
+	rewardsStaker string
+	rewardsFrom   string
+	rewardsTo     string
+)
+
+type StakerConfig struct {
+	ArchiveRpc string `mapstructure:"archive_rpc"`
+}
+
+// vaultSnapshot is the state of a prover vault at the end of a block
+type vaultSnapshot struct {
+	Block         uint64
+	Vault         *bindings.IProverVault
+	ShareUnit     *big.Int // one whole share, 10^decimals
+	ShareValue    *big.Int // assets of one whole share
+	TotalAssets   *big.Int
+	SlashingScale *big.Int
+}
+
+// proverRewards attributes the share value growth of a prover vault between two snapshots
+type proverRewards struct {
+	Prover           common.Address
+	Start, End       *vaultSnapshot
+	RewardsToStakers *big.Int // from RewardsAdded events
+	Slashed          *big.Int // from StakingController ProverSlashed events
+}
+
+// position is a staker's vault shares of one prover and the stake flows in between
+type position struct {
+	Prover                 common.Address
+	SharesStart, SharesEnd *big.Int
+	ValueStart, ValueEnd   *big.Int
+	Staked, Unstaked       *big.Int
+	flows                  []positionFlow
+}
+
+// positionFlow is a stake (positive) or unstake request (negative) in assets
+type positionFlow struct {
+	Block  uint64
+	Amount *big.Int
+}
+
+func StakerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "staker",
+		Short: "staker reporting",
+	}
+	rewards := &cobra.Command{
+		Use:   "rewards",
+		Short: "attribute prover vault share value growth to rewards and slashing, and report realized APR",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stakerRewards()
+		},
+	}
+	rewards.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(rewards.Flags(), "staker", StakerConfig{})
+	rewards.Flags().StringVar(&rewardsProver, FlagProver, "", "prover address, default to all provers")
+	rewards.Flags().StringVar(&rewardsStaker, FlagStaker, "", "staker address, also report the staker's positions")
+	rewards.Flags().StringVar(&rewardsFrom, FlagFrom, "", "first day (YYYY-MM-DD, UTC) or block number")
+	rewards.Flags().StringVar(&rewardsTo, FlagTo, "", "last day (YYYY-MM-DD, UTC) or block number, default to latest block")
+	addBlkDeltaFlag(rewards.Flags())
+	rewards.MarkFlagRequired(FlagFrom)
+	cmd.AddCommand(rewards)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(StakerCmd())
+}
+
+func stakerRewards() error {
+	c, ec, err := dialArchive("staker")
+	if err != nil {
+		return err
+	}
+	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+	token, err := getStakingToken(ec, c)
+	chkErr(err, "getStakingToken")
+
+	var provers []common.Address
+	if rewardsProver != "" {
+		if !common.IsHexAddress(rewardsProver) {
+			return fmt.Errorf("invalid prover address %s", rewardsProver)
+		}
+		provers = []common.Address{common.HexToAddress(rewardsProver)}
+	} else {
+		provers, err = listProvers(stakingController)
+		chkErr(err, "listProvers")
+	}
+	var staker common.Address
+	if rewardsStaker != "" {
+		if !common.IsHexAddress(rewardsStaker) {
+			return fmt.Errorf("invalid staker address %s", rewardsStaker)
+		}
+		staker = common.HexToAddress(rewardsStaker)
+	}
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	from, err := parseBlockOrDate(ec, rewardsFrom, head, false)
+	if err != nil {
+		return err
+	}
+	to := head
+	if rewardsTo != "" {
+		if to, err = parseBlockOrDate(ec, rewardsTo, head, true); err != nil {
+			return err
+		}
+	}
+	if from >= to {
+		return fmt.Errorf("from block %d is not before to block %d", from, to)
+	}
+	blkTime := newBlockTimes(ec)
+	t0, err := blkTime.get(from)
+	chkErr(err, "block time")
+	t1, err := blkTime.get(to)
+	chkErr(err, "block time")
+	if t1 <= t0 {
+		return fmt.Errorf("blocks %d and %d have the same timestamp", from, to)
+	}
+	log.Printf("snapshotting %d provers at blocks %d and %d, %.2f days apart", len(provers), from, to, float64(t1-t0)/86400)
+
+	var reports []*proverRewards
+	byProver := make(map[common.Address]*proverRewards)
+	for _, prover := range provers {
+		start, err := takeVaultSnapshot(ec, stakingController, prover, from)
+		if err != nil {
+			return err
+		}
+		if start == nil {
+			log.Printf("prover %s has no vault at block %d, skipped", prover.Hex(), from)
+			continue
+		}
+		end, err := takeVaultSnapshot(ec, stakingController, prover, to)
+		if err != nil {
+			return err
+		}
+		pr := &proverRewards{Prover: prover, Start: start, End: end, RewardsToStakers: big.NewInt(0), Slashed: big.NewInt(0)}
+		reports = append(reports, pr)
+		byProver[prover] = pr
+	}
+	if len(reports) == 0 {
+		return fmt.Errorf("no prover vault to report on at block %d", from)
+	}
+
+	positions := make(map[common.Address]*position)
+	if staker != ZeroAddr {
+		for _, pr := range reports {
+			pos, err := takePosition(stakingController, pr, staker)
+			if err != nil {
+				return err
+			}
+			positions[pr.Prover] = pos
+		}
+	}
+
+	// the snapshots are taken at the end of block from, so events count from the next block
+	err = scanRewardEvents(ec, stakingController, byProver, positions, staker, from+1, to)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVER\tSHARE_VALUE_START\tSHARE_VALUE_END\tASSETS_START\tASSETS_END\tREWARDS_TO_STAKERS\tSLASHED\tREWARD_GROWTH\tSLASH_LOSS\tNET_GROWTH\tAPR")
+	for _, pr := range reports {
+		// share value growth splits into the slashing scale change and the rest, which is rewards
+		net := ratio(pr.End.ShareValue, pr.Start.ShareValue)
+		slash := ratio(pr.End.SlashingScale, pr.Start.SlashingScale)
+		reward := 0.0
+		if slash > 0 {
+			reward = net / slash
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pr.Prover.Hex(),
+			token.fmt(pr.Start.ShareValue), token.fmt(pr.End.ShareValue), token.fmt(pr.Start.TotalAssets), token.fmt(pr.End.TotalAssets),
+			token.fmt(pr.RewardsToStakers), token.fmt(pr.Slashed),
+			fmtPct(reward-1), fmtPct(1-slash), fmtPct(net-1), fmtPct((net-1)*secondsPerYear/float64(t1-t0)))
+	}
+	w.Flush()
+
+	if staker == ZeroAddr {
+		return nil
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVER\tSHARES_START\tSHARES_END\tVALUE_START\tVALUE_END\tSTAKED\tUNSTAKED\tEARNED\tAPR")
+	for _, pr := range reports {
+		pos := positions[pr.Prover]
+		if pos.SharesStart.Sign() == 0 && pos.SharesEnd.Sign() == 0 && len(pos.flows) == 0 {
+			continue
+		}
+		earned, apr, err := pos.earned(blkTime, t0, t1)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pr.Prover.Hex(),
+			pos.SharesStart, pos.SharesEnd, token.fmt(pos.ValueStart), token.fmt(pos.ValueEnd), token.fmt(pos.Staked), token.fmt(pos.Unstaked), token.fmt(earned), apr)
+	}
+	w.Flush()
+	return nil
+}
+
+// takeVaultSnapshot reads the prover vault at the end of block blk, nil if the prover had no vault yet
+func takeVaultSnapshot(ec *ethclient.Client, stakingController *bindings.IStakingController, prover common.Address, blk uint64) (*vaultSnapshot, error) {
+	opts := &bind.CallOpts{BlockNumber: new(big.Int).SetUint64(blk)}
+	vaultAddr, err := stakingController.GetProverVault(opts, prover)
+	if err != nil {
+		return nil, historicalErr("GetProverVault", blk, err)
+	}
+	if vaultAddr == ZeroAddr {
+		return nil, nil
+	}
+	vault, err := bindings.NewIProverVault(vaultAddr, ec)
+	chkErr(err, "NewIProverVault")
+	decimals, err := vault.Decimals(opts)
+	if err != nil {
+		return nil, historicalErr("vault decimals", blk, err)
+	}
+	s := &vaultSnapshot{Block: blk, Vault: vault, ShareUnit: new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)}
+	if s.ShareValue, err = vault.ConvertToAssets(opts, s.ShareUnit); err != nil {
+		return nil, historicalErr("vault convertToAssets", blk, err)
+	}
+	if s.TotalAssets, err = stakingController.GetProverTotalAssets(opts, prover); err != nil {
+		return nil, historicalErr("GetProverTotalAssets", blk, err)
+	}
+	if s.SlashingScale, err = stakingController.GetProverSlashingScale(opts, prover); err != nil {
+		return nil, historicalErr("GetProverSlashingScale", blk, err)
+	}
+	return s, nil
+}
+
+// takePosition reads the staker's shares and their value at both snapshots of pr
+func takePosition(stakingController *bindings.IStakingController, pr *proverRewards, staker common.Address) (*position, error) {
+	pos := &position{Prover: pr.Prover, Staked: big.NewInt(0), Unstaked: big.NewInt(0)}
+	for _, s := range []*vaultSnapshot{pr.Start, pr.End} {
+		opts := &bind.CallOpts{BlockNumber: new(big.Int).SetUint64(s.Block)}
+		shares, err := stakingController.GetStakeInfo(opts, pr.Prover, staker)
+		if err != nil {
+			return nil, historicalErr("GetStakeInfo", s.Block, err)
+		}
+		value, err := s.Vault.ConvertToAssets(opts, shares)
+		if err != nil {
+			return nil, historicalErr("vault convertToAssets", s.Block, err)
+		}
+		if s == pr.Start {
+			pos.SharesStart, pos.ValueStart = shares, value
+		} else {
+			pos.SharesEnd, pos.ValueEnd = shares, value
+		}
+	}
+	return pos, nil
+}
+
+// scanRewardEvents sums rewards and slashes of the reported provers and collects
+// the staker's stake flows if staker is set
+func scanRewardEvents(ec *ethclient.Client, stakingController *bindings.IStakingController, byProver map[common.Address]*proverRewards,
+	positions map[common.Address]*position, staker common.Address, from, to uint64) error {
+	var provers []common.Address
+	if rewardsProver != "" {
+		provers = []common.Address{common.HexToAddress(rewardsProver)}
+	}
+	type flow struct {
+		prover common.Address
+		positionFlow
+	}
+	sc := scanner.NewScanner(ec, from, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	return sc.ScanTo(context.Background(), to, func(r *scanner.Range) error {
+		rewards := make(map[common.Address]*big.Int)
+		slashed := make(map[common.Address]*big.Int)
+		var flows []flow
+		addTo := func(m map[common.Address]*big.Int, prover common.Address, amt *big.Int) {
+			if byProver[prover] == nil {
+				return
+			}
+			if m[prover] == nil {
+				m[prover] = big.NewInt(0)
+			}
+			m[prover].Add(m[prover], amt)
+		}
+
+		err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerRewardsAddedIterator, error) {
+			return stakingController.FilterRewardsAdded(opts, provers, nil)
+		}, func(it *bindings.IStakingControllerRewardsAddedIterator) error {
+			addTo(rewards, it.Event.Prover, it.Event.ToStakers)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("RewardsAdded: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerProverSlashedIterator, error) {
+			return stakingController.FilterProverSlashed(opts, provers)
+		}, func(it *bindings.IStakingControllerProverSlashedIterator) error {
+			addTo(slashed, it.Event.Prover, it.Event.Amount)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ProverSlashed: %w", err)
+		}
+
+		if staker != ZeroAddr {
+			stakers := []common.Address{staker}
+			err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerStakedIterator, error) {
+				return stakingController.FilterStaked(opts, provers, stakers)
+			}, func(it *bindings.IStakingControllerStakedIterator) error {
+				flows = append(flows, flow{it.Event.Prover, positionFlow{it.Event.Raw.BlockNumber, it.Event.Amount}})
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("Staked: %w", err)
+			}
+			err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerUnstakeRequestedIterator, error) {
+				return stakingController.FilterUnstakeRequested(opts, provers, stakers)
+			}, func(it *bindings.IStakingControllerUnstakeRequestedIterator) error {
+				flows = append(flows, flow{it.Event.Prover, positionFlow{it.Event.Raw.BlockNumber, new(big.Int).Neg(it.Event.Amount)}})
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("UnstakeRequested: %w", err)
+			}
+		}
+
+		for prover, amt := range rewards {
+			byProver[prover].RewardsToStakers.Add(byProver[prover].RewardsToStakers, amt)
+		}
+		for prover, amt := range slashed {
+			byProver[prover].Slashed.Add(byProver[prover].Slashed, amt)
+		}
+		for _, f := range flows {
+			pos := positions[f.prover]
+			if pos == nil {
+				continue
+			}
+			if f.Amount.Sign() > 0 {
+				pos.Staked.Add(pos.Staked, f.Amount)
+			} else {
+				pos.Unstaked.Sub(pos.Unstaked, f.Amount)
+			}
+			pos.flows = append(pos.flows, f.positionFlow)
+		}
+		return nil
+	})
+}
+
+// earned returns what the position earned net of stake flows, and its APR by
+// the modified Dietz method, which weights each flow by the time it was invested.
+// An unstake request counts as withdrawn at the request
+func (pos *position) earned(blkTime *blockTimes, t0, t1 uint64) (*big.Int, string, error) {
+	earned := new(big.Int).Sub(pos.ValueEnd, pos.ValueStart)
+	earned.Sub(earned, pos.Staked)
+	earned.Add(earned, pos.Unstaked)
+
+	span := float64(t1 - t0)
+	capital, _ := new(big.Float).SetInt(pos.ValueStart).Float64()
+	for _, f := range pos.flows {
+		t, err := blkTime.get(f.Block)
+		if err != nil {
+			return nil, "", err
+		}
+		amt, _ := new(big.Float).SetInt(f.Amount).Float64()
+		capital += amt * float64(t1-t) / span
+	}
+	if capital <= 0 {
+		return earned, "-", nil
+	}
+	gain, _ := new(big.Float).SetInt(earned).Float64()
+	return earned, fmtPct(gain / capital * secondsPerYear / span), nil
+}
+
+// blockTimes caches block timestamps
+type blockTimes struct {
+	ec    *ethclient.Client
+	times map[uint64]uint64
+}
+
+func newBlockTimes(ec *ethclient.Client) *blockTimes {
+	return &blockTimes{ec: ec, times: make(map[uint64]uint64)}
+}
+
+func (b *blockTimes) get(blk uint64) (uint64, error) {
+	if t, ok := b.times[blk]; ok {
+		return t, nil
+	}
+	header, err := b.ec.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blk))
+	if err != nil {
+		return 0, fmt.Errorf("HeaderByNumber %d: %w", blk, err)
+	}
+	b.times[blk] = header.Time
+	return header.Time, nil
+}
+
+// dialArchive reads the chain config and dials archive_rpc of the section if
+// set, as past state is only served by archive nodes, else chain.chain_rpc
+func dialArchive(section string) (*ChainConfig, *ethclient.Client, error) {
+	err := readConfig()
+	chkErr(err, "readConfig")
+
+	var c ChainConfig
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
+	var ac struct {
+		ArchiveRpc string `mapstructure:"archive_rpc"`
+	}
+	err = unmarshalConfig(section, &ac)
+	chkErr(err, "unmarshalConfig")
+
+	rpc := c.ChainRpc
+	if ac.ArchiveRpc != "" {
+		rpc = ac.ArchiveRpc
+	}
+	ec, err := ethclient.Dial(rpc)
+	chkErr(err, "Dial")
+	chid, err := ec.ChainID(context.Background())
+	chkErr(err, "ChainID")
+	if chid.Uint64() != c.ChainID {
+		return nil, nil, fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
+	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
+	return &c, ec, nil
+}
+
+// historicalErr points at the rpc if it has pruned the state of blk
+func historicalErr(msg string, blk uint64, err error) error {
+	lower := strings.ToLower(err.Error())
+	for _, frag := range []string{"missing trie node", "historical state", "state not available", "pruned", "header not found"} {
+		if strings.Contains(lower, frag) {
+			return fmt.Errorf("%s at block %d: %w, set archive_rpc in the [staker] or [treasury] config to an archive node", msg, blk, err)
+		}
+	}
+	return fmt.Errorf("%s at block %d: %w", msg, blk, err)
+}
+
+// ratio returns a/b, 0 if b is zero
+func ratio(a, b *big.Int) float64 {
+	if b.Sign() == 0 {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(a, b).Float64()
+	return f
+}
+
+func fmtPct(f float64) string {
+	return fmt.Sprintf("%.4f%%", f*100)
+}
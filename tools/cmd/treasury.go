@@ -0,0 +1,434 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	ledgerFrom string
+	ledgerTo   string
+	ledgerOut  string
+)
+
+type TreasuryConfig struct {
+	ProtocolFeeTo     string `mapstructure:"protocol_fee_to"`
+	StakingTreasuryTo string `mapstructure:"staking_treasury_to"`
+	ArchiveRpc        string `mapstructure:"archive_rpc"`
+}
+
+// ledgerEntry is a treasury movement, Amount is positive for inflows
+type ledgerEntry struct {
+	Block    uint64
+	Index    uint
+	Time     uint64
+	Contract string // market or staking
+	Event    string
+	Account  common.Address // recipient of withdrawals, slashed prover of inflows
+	Amount   *big.Int
+	// market only, CumulativeProtocolFee and WithdrawnProtocolFee after the event
+	Accrued, Withdrawn *big.Int
+}
+
+// protocolFeeState is the protocol fee accounting of BrevisMarket at a block
+type protocolFeeState struct {
+	Block     uint64
+	Accrued   *big.Int
+	Withdrawn *big.Int
+	Balance   *big.Int
+}
+
+func TreasuryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "treasury",
+		Short: "report and withdraw market protocol fees and the staking treasury",
+	}
+	addAccessFlags(cmd)
+	addConfigFlags(cmd.PersistentFlags(), "treasury", TreasuryConfig{})
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "print accrued, withdrawn and withdrawable protocol fees, and staking controller balances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return treasuryShow()
+		},
+	}
+	ledger := &cobra.Command{
+		Use:   "ledger",
+		Short: "list treasury inflows and withdrawals in a block range and reconcile them with the onchain totals",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return treasuryLedger()
+		},
+	}
+	ledger.Flags().StringVar(&ledgerFrom, FlagFrom, "", "first day (YYYY-MM-DD, UTC) or block number, default to index.start_block or the market deploy block")
+	ledger.Flags().StringVar(&ledgerTo, FlagTo, "", "last day (YYYY-MM-DD, UTC) or block number, default to latest block")
+	ledger.Flags().StringVar(&ledgerOut, FlagOut, "", "also write the ledger to this csv file")
+	addBlkDeltaFlag(ledger.Flags())
+	withdraw := &cobra.Command{
+		Use:   "withdraw <market|staking> [amount]",
+		Short: "withdraw all protocol fees, or amount from the staking treasury, to the destination in [treasury] config",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return treasuryWithdraw(args)
+		},
+	}
+	cmd.AddCommand(show, ledger, withdraw)
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(TreasuryCmd())
+}
+
+func getProtocolFeeState(brevisMarket *bindings.BrevisMarket, blk uint64) (*protocolFeeState, error) {
+	opts := &bind.CallOpts{BlockNumber: new(big.Int).SetUint64(blk)}
+	s := &protocolFeeState{Block: blk}
+	var err error
+	if s.Accrued, err = brevisMarket.CumulativeProtocolFee(opts); err != nil {
+		return nil, historicalErr("CumulativeProtocolFee", blk, err)
+	}
+	if s.Withdrawn, err = brevisMarket.WithdrawnProtocolFee(opts); err != nil {
+		return nil, historicalErr("WithdrawnProtocolFee", blk, err)
+	}
+	info, err := brevisMarket.GetProtocolFeeInfo(opts)
+	if err != nil {
+		return nil, historicalErr("GetProtocolFeeInfo", blk, err)
+	}
+	s.Balance = info.Balance
+	return s, nil
+}
+
+// check returns an error if the withdrawable balance is not accrued minus withdrawn
+func (s *protocolFeeState) check() error {
+	expected := new(big.Int).Sub(s.Accrued, s.Withdrawn)
+	if expected.Cmp(s.Balance) != 0 {
+		return fmt.Errorf("at block %d accrued %s - withdrawn %s = %s but withdrawable balance is %s",
+			s.Block, s.Accrued, s.Withdrawn, expected, s.Balance)
+	}
+	return nil
+}
+
+func treasuryShow() error {
+	c, ec, _, brevisMarket, err := dialMarket()
+	if err != nil {
+		return err
+	}
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	fee, err := getProtocolFeeState(brevisMarket, head)
+	if err != nil {
+		return err
+	}
+	feeInfo, err := brevisMarket.GetProtocolFeeInfo(nil)
+	chkErr(err, "GetProtocolFeeInfo")
+	feeToken, err := getFeeToken(ec, c)
+	chkErr(err, "getFeeToken")
+
+	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+	stakingToken, err := stakingController.StakingToken(nil)
+	chkErr(err, "StakingToken")
+	stakeToken, err := getToken(ec, stakingToken)
+	chkErr(err, "getToken")
+	erc20, err := bindings.NewIERC20(stakingToken, ec)
+	chkErr(err, "NewIERC20")
+	balance, err := erc20.BalanceOf(nil, common.HexToAddress(c.StakingControllerAddr))
+	chkErr(err, "BalanceOf")
+	staked := big.NewInt(0)
+	for _, isActive := range []bool{true, false} {
+		assets, err := stakingController.GetTotalVaultAssets(nil, isActive)
+		chkErr(err, "GetTotalVaultAssets")
+		staked.Add(staked, assets)
+	}
+	provers, err := listProvers(stakingController)
+	chkErr(err, "listProvers")
+	unstaking := big.NewInt(0)
+	for _, p := range provers {
+		amt, err := stakingController.GetProverTotalUnstaking(nil, p)
+		chkErr(err, "GetProverTotalUnstaking")
+		unstaking.Add(unstaking, amt)
+	}
+	rest := new(big.Int).Sub(balance, staked)
+	rest.Sub(rest, unstaking)
+
+	fmt.Printf("BrevisMarket %s, fee token %s (%s), protocol fee %s at block %d\n", c.BrevisMarketAddr, feeToken.Addr.Hex(), feeToken.Symbol, fmtBps(feeInfo.FeeBps), head)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "accrued:\t%s\n", feeToken.fmt(fee.Accrued))
+	fmt.Fprintf(w, "withdrawn:\t%s\n", feeToken.fmt(fee.Withdrawn))
+	fmt.Fprintf(w, "withdrawable:\t%s\n", feeToken.fmt(fee.Balance))
+	w.Flush()
+	if err = fee.check(); err != nil {
+		log.Printf("WARNING: %s", err)
+	}
+	fmt.Printf("\nStakingController %s, staking token %s (%s)\n", c.StakingControllerAddr, stakingToken.Hex(), stakeToken.Symbol)
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "token balance:\t%s\n", stakeToken.fmt(balance))
+	fmt.Fprintf(w, "vault assets:\t%s\n", stakeToken.fmt(staked))
+	fmt.Fprintf(w, "pending unstakes:\t%s\n", stakeToken.fmt(unstaking))
+	fmt.Fprintf(w, "treasury and other:\t%s\n", stakeToken.fmt(rest))
+	w.Flush()
+	return nil
+}
+
+func treasuryLedger() error {
+	c, ec, err := dialArchive("treasury")
+	if err != nil {
+		return err
+	}
+	brevisMarket, err := bindings.NewBrevisMarket(common.HexToAddress(c.BrevisMarketAddr), ec)
+	chkErr(err, "NewBrevisMarket")
+	stakingController, err := bindings.NewIStakingController(common.HexToAddress(c.StakingControllerAddr), ec)
+	chkErr(err, "NewIStakingController")
+	feeToken, err := getFeeToken(ec, c)
+	chkErr(err, "getFeeToken")
+	stakeToken, err := getStakingToken(ec, c)
+	chkErr(err, "getStakingToken")
+
+	head, err := ec.BlockNumber(context.Background())
+	chkErr(err, "BlockNumber")
+	from := viper.GetUint64("index.start_block")
+	if ledgerFrom != "" {
+		if from, err = parseBlockOrDate(ec, ledgerFrom, head, false); err != nil {
+			return err
+		}
+	} else if from == 0 {
+		if from, err = deployBlock(ec, common.HexToAddress(c.BrevisMarketAddr)); err != nil {
+			return fmt.Errorf("market deploy block: %w, set --%s or index.start_block", err, FlagFrom)
+		}
+	}
+	to := head
+	if ledgerTo != "" {
+		if to, err = parseBlockOrDate(ec, ledgerTo, head, true); err != nil {
+			return err
+		}
+	}
+	if from >= to {
+		return fmt.Errorf("from block %d is not before to block %d", from, to)
+	}
+
+	// opening balances are at the end of the block before from, so events of from are in the ledger
+	openingBlk := from
+	if from > 0 {
+		openingBlk = from - 1
+	}
+	// the market may not be deployed yet then, its totals start from zero
+	opening := &protocolFeeState{Block: openingBlk, Accrued: big.NewInt(0), Withdrawn: big.NewInt(0), Balance: big.NewInt(0)}
+	code, err := ec.CodeAt(context.Background(), common.HexToAddress(c.BrevisMarketAddr), new(big.Int).SetUint64(openingBlk))
+	if err != nil {
+		return historicalErr("CodeAt", openingBlk, err)
+	}
+	if len(code) > 0 {
+		if opening, err = getProtocolFeeState(brevisMarket, openingBlk); err != nil {
+			return err
+		}
+	}
+	closing, err := getProtocolFeeState(brevisMarket, to)
+	if err != nil {
+		return err
+	}
+	entries, err := scanTreasuryEvents(ec, brevisMarket, stakingController, from, to)
+	if err != nil {
+		return err
+	}
+	blkTime := newBlockTimes(ec)
+	for _, e := range entries {
+		if e.Time, err = blkTime.get(e.Block); err != nil {
+			return err
+		}
+		if e.Contract != "market" {
+			continue
+		}
+		// accrued and withdrawn right after the withdrawal, so the ledger shows accrual over time
+		s, err := getProtocolFeeState(brevisMarket, e.Block)
+		if err != nil {
+			return err
+		}
+		e.Accrued, e.Withdrawn = s.Accrued, s.Withdrawn
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCK\tTIME\tCONTRACT\tEVENT\tACCOUNT\tAMOUNT\tFEE_ACCRUED\tFEE_WITHDRAWN")
+	for _, e := range entries {
+		amount, accrued, withdrawn := stakeToken.fmt(e.Amount), "", ""
+		if e.Contract == "market" {
+			amount, accrued, withdrawn = feeToken.fmt(e.Amount), feeToken.fmt(e.Accrued), feeToken.fmt(e.Withdrawn)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Block, fmtUnix(e.Time), e.Contract, e.Event, e.Account.Hex(), amount, accrued, withdrawn)
+	}
+	w.Flush()
+
+	withdrawn, slashed, treasuryOut, recovered := big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	for _, e := range entries {
+		switch e.Event {
+		case "ProtocolFeeWithdrawn":
+			withdrawn.Sub(withdrawn, e.Amount)
+		case "ProverSlashed":
+			slashed.Add(slashed, e.Amount)
+		case "TreasuryWithdrawn":
+			treasuryOut.Sub(treasuryOut, e.Amount)
+		case "EmergencyRecovered":
+			recovered.Sub(recovered, e.Amount)
+		}
+	}
+	fmt.Printf("\nprotocol fee, blocks %d to %d\n", from, to)
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tOPENING\tCHANGE\tCLOSING")
+	fmt.Fprintf(w, "accrued\t%s\t%s\t%s\n", feeToken.fmt(opening.Accrued), feeToken.fmt(new(big.Int).Sub(closing.Accrued, opening.Accrued)), feeToken.fmt(closing.Accrued))
+	fmt.Fprintf(w, "withdrawn\t%s\t%s\t%s\n", feeToken.fmt(opening.Withdrawn), feeToken.fmt(withdrawn), feeToken.fmt(closing.Withdrawn))
+	fmt.Fprintf(w, "withdrawable\t%s\t%s\t%s\n", feeToken.fmt(opening.Balance), feeToken.fmt(new(big.Int).Sub(closing.Balance, opening.Balance)), feeToken.fmt(closing.Balance))
+	w.Flush()
+	reconciled := true
+	for _, s := range []*protocolFeeState{opening, closing} {
+		if err = s.check(); err != nil {
+			log.Printf("MISMATCH: %s", err)
+			reconciled = false
+		}
+	}
+	if change := new(big.Int).Sub(closing.Withdrawn, opening.Withdrawn); change.Cmp(withdrawn) != 0 {
+		log.Printf("MISMATCH: WithdrawnProtocolFee grew by %s but ProtocolFeeWithdrawn events sum to %s", change, withdrawn)
+		reconciled = false
+	}
+	if reconciled {
+		log.Println("protocol fee ledger reconciled with onchain totals")
+	}
+	fmt.Println("\nstaking treasury")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "slashed in:\t%s\n", stakeToken.fmt(slashed))
+	fmt.Fprintf(w, "treasury withdrawn:\t%s\n", stakeToken.fmt(treasuryOut))
+	fmt.Fprintf(w, "emergency recovered:\t%s\n", stakeToken.fmt(recovered))
+	w.Flush()
+
+	if ledgerOut == "" {
+		return nil
+	}
+	f, err := os.Create(ledgerOut)
+	chkErr(err, "create csv")
+	defer f.Close()
+	cw := csv.NewWriter(f)
+	cw.Write([]string{"block", "time", "contract", "event", "account", "amount", "protocol_fee_accrued", "protocol_fee_withdrawn"})
+	// opening and closing rows let the ledger be reconciled on its own
+	cw.Write([]string{strconv.FormatUint(opening.Block, 10), "", "market", "opening", "", opening.Balance.String(), opening.Accrued.String(), opening.Withdrawn.String()})
+	for _, e := range entries {
+		cw.Write([]string{strconv.FormatUint(e.Block, 10), time.Unix(int64(e.Time), 0).UTC().Format(time.RFC3339), e.Contract, e.Event,
+			e.Account.Hex(), e.Amount.String(), fmtOptionalInt(e.Accrued), fmtOptionalInt(e.Withdrawn)})
+	}
+	cw.Write([]string{strconv.FormatUint(closing.Block, 10), "", "market", "closing", "", closing.Balance.String(), closing.Accrued.String(), closing.Withdrawn.String()})
+	cw.Flush()
+	chkErr(cw.Error(), "write csv")
+	log.Printf("ledger written to %s", ledgerOut)
+	return nil
+}
+
+func scanTreasuryEvents(ec *ethclient.Client, brevisMarket *bindings.BrevisMarket, stakingController *bindings.IStakingController, from, to uint64) ([]*ledgerEntry, error) {
+	var entries []*ledgerEntry
+	sc := scanner.NewScanner(ec, from, nil, scanner.Config{MaxBlkDelta: blkDelta})
+	err := sc.ScanTo(context.Background(), to, func(r *scanner.Range) error {
+		var found []*ledgerEntry
+		err := scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketProtocolFeeWithdrawnIterator, error) {
+			return brevisMarket.FilterProtocolFeeWithdrawn(opts, nil)
+		}, func(it *bindings.BrevisMarketProtocolFeeWithdrawnIterator) error {
+			found = append(found, &ledgerEntry{Block: it.Event.Raw.BlockNumber, Index: it.Event.Raw.Index, Contract: "market",
+				Event: "ProtocolFeeWithdrawn", Account: it.Event.To, Amount: new(big.Int).Neg(it.Event.Amount)})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ProtocolFeeWithdrawn: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerProverSlashedIterator, error) {
+			return stakingController.FilterProverSlashed(opts, nil)
+		}, func(it *bindings.IStakingControllerProverSlashedIterator) error {
+			found = append(found, &ledgerEntry{Block: it.Event.Raw.BlockNumber, Index: it.Event.Raw.Index, Contract: "staking",
+				Event: "ProverSlashed", Account: it.Event.Prover, Amount: it.Event.Amount})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ProverSlashed: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerTreasuryWithdrawnIterator, error) {
+			return stakingController.FilterTreasuryWithdrawn(opts, nil)
+		}, func(it *bindings.IStakingControllerTreasuryWithdrawnIterator) error {
+			found = append(found, &ledgerEntry{Block: it.Event.Raw.BlockNumber, Index: it.Event.Raw.Index, Contract: "staking",
+				Event: "TreasuryWithdrawn", Account: it.Event.To, Amount: new(big.Int).Neg(it.Event.Amount)})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("TreasuryWithdrawn: %w", err)
+		}
+		err = scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.IStakingControllerEmergencyRecoveredIterator, error) {
+			return stakingController.FilterEmergencyRecovered(opts)
+		}, func(it *bindings.IStakingControllerEmergencyRecoveredIterator) error {
+			found = append(found, &ledgerEntry{Block: it.Event.Raw.BlockNumber, Index: it.Event.Raw.Index, Contract: "staking",
+				Event: "EmergencyRecovered", Account: it.Event.To, Amount: new(big.Int).Neg(it.Event.Amount)})
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("EmergencyRecovered: %w", err)
+		}
+		entries = append(entries, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Block != entries[j].Block {
+			return entries[i].Block < entries[j].Block
+		}
+		return entries[i].Index < entries[j].Index
+	})
+	return entries, nil
+}
+
+func treasuryWithdraw(args []string) error {
+	err := readConfig()
+	chkErr(err, "readConfig")
+	var tc TreasuryConfig
+	err = unmarshalConfig("treasury", &tc)
+	chkErr(err, "unmarshalConfig")
+
+	switch args[0] {
+	case "market":
+		if len(args) > 1 {
+			return fmt.Errorf("the protocol fee is withdrawn in full, no amount is taken")
+		}
+		if tc.ProtocolFeeTo == "" {
+			return fmt.Errorf("treasury.protocol_fee_to is not set in config")
+		}
+		return adminMarketWithdrawProtocolFee(tc.ProtocolFeeTo)
+	case "staking":
+		if len(args) < 2 {
+			return fmt.Errorf("amount is required to withdraw from the staking treasury")
+		}
+		if tc.StakingTreasuryTo == "" {
+			return fmt.Errorf("treasury.staking_treasury_to is not set in config")
+		}
+		return adminStakingWithdraw("withdrawTreasury", tc.StakingTreasuryTo, args[1])
+	default:
+		return fmt.Errorf("invalid contract %q, should be market or staking", args[0])
+	}
+}
+
+func fmtOptionalInt(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
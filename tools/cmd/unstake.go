@@ -15,7 +15,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type UnstakeConfig struct {
@@ -31,8 +30,8 @@ func UnstakeCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&config, FlagConfig, "", "config file path")
+	addConfigFlags(cmd.Flags(), "unstake", UnstakeConfig{})
 	cmd.Flags().StringVar(&stage, FlagStage, "request", "request or complete")
-	cmd.MarkFlagRequired(FlagConfig)
 	cmd.MarkFlagRequired(FlagStage)
 	return cmd
 }
@@ -50,13 +49,12 @@ func init() {
 }
 
 func unstake() error {
-	viper.SetConfigFile(config)
-	err := viper.ReadInConfig()
-	chkErr(err, "ReadInConfig")
+	err := readConfig()
+	chkErr(err, "readConfig")
 
 	var c ChainConfig
-	err = viper.UnmarshalKey("chain", &c)
-	chkErr(err, "UnmarshalKey")
+	err = loadChainConfig(&c)
+	chkErr(err, "loadChainConfig")
 
 	ec, err := ethclient.Dial(c.ChainRpc)
 	chkErr(err, "Dial")
@@ -65,10 +63,12 @@ func unstake() error {
 	if chid.Uint64() != c.ChainID {
 		return fmt.Errorf("chainid mismatch! cfg has %d but onchain has %d", c.ChainID, chid.Uint64())
 	}
+	err = checkNetwork(ec, &c)
+	chkErr(err, "checkNetwork")
 
 	var s UnstakeConfig
-	err = viper.UnmarshalKey("unstake", &s)
-	chkErr(err, "UnmarshalKey")
+	err = unmarshalConfig("unstake", &s)
+	chkErr(err, "unmarshalConfig")
 
 	auth, sender, err := CreateTransactOpts(c.Keystore, c.Passphrase, chid)
 	chkErr(err, "prover CreateTransactOpts")
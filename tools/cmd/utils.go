@@ -7,12 +7,14 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"tools/bindings"
 
 	"github.com/celer-network/goutils/eth"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/pflag"
 )
 
 var ZeroAddr common.Address
@@ -88,16 +90,55 @@ func ParseSolCustomErrorName(contractABI string, errData []byte) (string, error)
 
 func checkBrevisCustomError(err error, logmsg string, contractABI string) {
 	if err != nil {
-		var jsonErr JsonError
-		errJson, _ := json.Marshal(err)
-		json.Unmarshal(errJson, &jsonErr)
-		if jsonErr.Data != "" && jsonErr.Data != "0x" {
-			errName, pErr := ParseSolCustomErrorName(contractABI, common.FromHex(jsonErr.Data))
-			chkErr(pErr, "ParseSolCustomErrorName")
-
+		if errName := customErrorName(err, contractABI); errName != "" {
 			log.Fatalf("%s, err %s - %s", logmsg, err.Error(), errName)
 		} else {
 			chkErr(err, logmsg)
 		}
 	}
 }
+
+// customErrorName returns the name of the solidity custom error in the revert
+// data of an rpc error, empty if there is none
+func customErrorName(err error, contractABI string) string {
+	var jsonErr JsonError
+	errJson, _ := json.Marshal(err)
+	json.Unmarshal(errJson, &jsonErr)
+	if jsonErr.Data == "" || jsonErr.Data == "0x" {
+		return ""
+	}
+	errName, pErr := ParseSolCustomErrorName(contractABI, common.FromHex(jsonErr.Data))
+	chkErr(pErr, "ParseSolCustomErrorName")
+	return errName
+}
+
+// listProvers returns all provers known to the staking controller, active or not
+func listProvers(stakingController *bindings.IStakingController) ([]common.Address, error) {
+	seen := make(map[common.Address]bool)
+	var provers []common.Address
+	for _, isActive := range []bool{true, false} {
+		count, err := stakingController.GetProverCount(nil, isActive)
+		if err != nil {
+			return nil, fmt.Errorf("GetProverCount: %w", err)
+		}
+		if count.Sign() == 0 {
+			continue
+		}
+		list, err := stakingController.GetProvers(nil, isActive, big.NewInt(0), count)
+		if err != nil {
+			return nil, fmt.Errorf("GetProvers: %w", err)
+		}
+		for _, p := range list {
+			if !seen[p] {
+				seen[p] = true
+				provers = append(provers, p)
+			}
+		}
+	}
+	return provers, nil
+}
+
+// addBlkDeltaFlag adds --blk-delta for commands that scan logs with the scanner
+func addBlkDeltaFlag(flags *pflag.FlagSet) {
+	flags.Uint64Var(&blkDelta, FlagBlkDelta, 5000, "max block range per log query, halved while the rpc rejects it as too large")
+}
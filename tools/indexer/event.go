@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package indexer
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"tools/bindings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	ContractBrevisMarket      = "BrevisMarket"
+	ContractStakingController = "StakingController"
+)
+
+// Event is a decoded contract event as stored in the index. ReqId, Prover,
+// Account and Amount are copied out of Data so they can be queried directly
+type Event struct {
+	BlockNumber uint64                 `json:"block_number"`
+	BlockHash   common.Hash            `json:"block_hash"`
+	TxHash      common.Hash            `json:"tx_hash"`
+	LogIndex    uint                   `json:"log_index"`
+	Contract    string                 `json:"contract"`
+	Address     common.Address         `json:"address"`
+	Name        string                 `json:"name"`
+	ReqId       string                 `json:"reqid,omitempty"`
+	Prover      string                 `json:"prover,omitempty"`
+	Account     string                 `json:"account,omitempty"`
+	Amount      string                 `json:"amount,omitempty"`
+	Data        map[string]interface{} `json:"data"`
+}
+
+type parseFunc func(l types.Log) (interface{}, error)
+
+// contract decodes logs of one contract with its generated binding
+type contract struct {
+	name    string
+	addr    common.Address
+	names   map[common.Hash]string
+	parsers map[common.Hash]parseFunc
+}
+
+// account and amount columns take the first field present in this order
+var (
+	accountFields = []string{"Staker", "Requester", "Submitter", "To", "Source"}
+	amountFields  = []string{"Amount", "ActualFee", "Fee", "SlashAmount"}
+)
+
+func newMarketContract(addr common.Address, f *bindings.BrevisMarketFilterer) (*contract, error) {
+	// admin and parameter events are left out on purpose
+	return newContract(ContractBrevisMarket, addr, bindings.BrevisMarketMetaData, map[string]parseFunc{
+		"NewRequest":              func(l types.Log) (interface{}, error) { return f.ParseNewRequest(l) },
+		"NewBid":                  func(l types.Log) (interface{}, error) { return f.ParseNewBid(l) },
+		"BidRevealed":             func(l types.Log) (interface{}, error) { return f.ParseBidRevealed(l) },
+		"ProofSubmitted":          func(l types.Log) (interface{}, error) { return f.ParseProofSubmitted(l) },
+		"Refunded":                func(l types.Log) (interface{}, error) { return f.ParseRefunded(l) },
+		"ProverSlashed":           func(l types.Log) (interface{}, error) { return f.ParseProverSlashed(l) },
+		"ProtocolFeeWithdrawn":    func(l types.Log) (interface{}, error) { return f.ParseProtocolFeeWithdrawn(l) },
+		"SubmitterRegistered":     func(l types.Log) (interface{}, error) { return f.ParseSubmitterRegistered(l) },
+		"SubmitterUnregistered":   func(l types.Log) (interface{}, error) { return f.ParseSubmitterUnregistered(l) },
+		"SubmitterConsentUpdated": func(l types.Log) (interface{}, error) { return f.ParseSubmitterConsentUpdated(l) },
+		"StatsEpochScheduled":     func(l types.Log) (interface{}, error) { return f.ParseStatsEpochScheduled(l) },
+		"StatsEpochPopped":        func(l types.Log) (interface{}, error) { return f.ParseStatsEpochPopped(l) },
+		"StatsReset":              func(l types.Log) (interface{}, error) { return f.ParseStatsReset(l) },
+	})
+}
+
+func newStakingContract(addr common.Address, f *bindings.IStakingControllerFilterer) (*contract, error) {
+	return newContract(ContractStakingController, addr, bindings.IStakingControllerMetaData, map[string]parseFunc{
+		"ProverInitialized":     func(l types.Log) (interface{}, error) { return f.ParseProverInitialized(l) },
+		"ProverStateChanged":    func(l types.Log) (interface{}, error) { return f.ParseProverStateChanged(l) },
+		"ProverRetired":         func(l types.Log) (interface{}, error) { return f.ParseProverRetired(l) },
+		"Staked":                func(l types.Log) (interface{}, error) { return f.ParseStaked(l) },
+		"UnstakeRequested":      func(l types.Log) (interface{}, error) { return f.ParseUnstakeRequested(l) },
+		"UnstakeCompleted":      func(l types.Log) (interface{}, error) { return f.ParseUnstakeCompleted(l) },
+		"CommissionClaimed":     func(l types.Log) (interface{}, error) { return f.ParseCommissionClaimed(l) },
+		"CommissionRateUpdated": func(l types.Log) (interface{}, error) { return f.ParseCommissionRateUpdated(l) },
+		"RewardsAdded":          func(l types.Log) (interface{}, error) { return f.ParseRewardsAdded(l) },
+		"ProverSlashed":         func(l types.Log) (interface{}, error) { return f.ParseProverSlashed(l) },
+		"TreasuryWithdrawn":     func(l types.Log) (interface{}, error) { return f.ParseTreasuryWithdrawn(l) },
+	})
+}
+
+func newContract(name string, addr common.Address, meta *bind.MetaData, parsers map[string]parseFunc) (*contract, error) {
+	contractAbi, err := meta.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	c := &contract{
+		name:    name,
+		addr:    addr,
+		names:   make(map[common.Hash]string),
+		parsers: make(map[common.Hash]parseFunc),
+	}
+	for evName, parse := range parsers {
+		ev, ok := contractAbi.Events[evName]
+		if !ok {
+			return nil, fmt.Errorf("%s abi has no event %s", name, evName)
+		}
+		c.names[ev.ID] = evName
+		c.parsers[ev.ID] = parse
+	}
+	return c, nil
+}
+
+// decode returns nil for logs of events that are not indexed
+func (c *contract) decode(l types.Log) (*Event, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil
+	}
+	parse, ok := c.parsers[l.Topics[0]]
+	if !ok {
+		return nil, nil
+	}
+	ev, err := parse(l)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s log %s:%d: %w", c.names[l.Topics[0]], l.TxHash.Hex(), l.Index, err)
+	}
+	data, _ := flatten(reflect.ValueOf(ev)).(map[string]interface{})
+	e := &Event{
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TxHash,
+		LogIndex:    l.Index,
+		Contract:    c.name,
+		Address:     l.Address,
+		Name:        c.names[l.Topics[0]],
+		Data:        data,
+	}
+	e.ReqId, _ = data["Reqid"].(string)
+	e.Prover, _ = data["Prover"].(string)
+	e.Account = firstField(data, accountFields)
+	e.Amount = firstField(data, amountFields)
+	return e, nil
+}
+
+func firstField(data map[string]interface{}, fields []string) string {
+	for _, f := range fields {
+		if v, ok := data[f].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// flatten turns a binding event struct into json friendly values, with
+// addresses and bytes32 as hex and big ints as decimal strings
+func flatten(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		if b, ok := v.Interface().(*big.Int); ok {
+			return b.String()
+		}
+		return flatten(v.Elem())
+	}
+	switch x := v.Interface().(type) {
+	case common.Address:
+		return x.Hex()
+	case [32]byte:
+		return common.Hash(x).Hex()
+	case []byte:
+		return hexutil.Encode(x)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			if name == "Raw" {
+				continue
+			}
+			m[name] = flatten(v.Field(i))
+		}
+		return m
+	case reflect.Array, reflect.Slice:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = flatten(v.Index(i))
+		}
+		return s
+	}
+	return v.Interface()
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"tools/bindings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type Config struct {
+	StartBlock uint64
+	Scanner    scanner.Config
+}
+
+// Indexer copies BrevisMarket and StakingController events into a Store
+type Indexer struct {
+	ec        *ethclient.Client
+	store     *Store
+	source    string
+	scanner   *scanner.Scanner
+	contracts map[common.Address]*contract
+}
+
+// SourceId is the key events and checkpoints of a deployment are stored under
+func SourceId(chainId uint64, brevisMarket, stakingController common.Address) string {
+	return strings.ToLower(fmt.Sprintf("%d:%s:%s", chainId, brevisMarket.Hex(), stakingController.Hex()))
+}
+
+func NewIndexer(ec *ethclient.Client, store *Store, chainId uint64, brevisMarket, stakingController common.Address, cfg Config) (*Indexer, error) {
+	marketFilterer, err := bindings.NewBrevisMarketFilterer(brevisMarket, ec)
+	if err != nil {
+		return nil, err
+	}
+	stakingFilterer, err := bindings.NewIStakingControllerFilterer(stakingController, ec)
+	if err != nil {
+		return nil, err
+	}
+	market, err := newMarketContract(brevisMarket, marketFilterer)
+	if err != nil {
+		return nil, err
+	}
+	staking, err := newStakingContract(stakingController, stakingFilterer)
+	if err != nil {
+		return nil, err
+	}
+	ix := &Indexer{
+		ec:     ec,
+		store:  store,
+		source: SourceId(chainId, brevisMarket, stakingController),
+		contracts: map[common.Address]*contract{
+			brevisMarket:      market,
+			stakingController: staking,
+		},
+	}
+	cp, err := store.Checkpoint(ix.source)
+	if err != nil {
+		return nil, fmt.Errorf("Checkpoint: %w", err)
+	}
+	ix.scanner = scanner.NewScanner(ec, cfg.StartBlock, cp, cfg.Scanner)
+	ix.scanner.OnReorg = func(cp *scanner.Checkpoint) error {
+		return store.Rewind(ix.source, cp)
+	}
+	return ix, nil
+}
+
+func (ix *Indexer) Source() string {
+	return ix.source
+}
+
+// Sync indexes from the checkpoint up to the confirmed head and returns the
+// new checkpoint block
+func (ix *Indexer) Sync(ctx context.Context) (uint64, error) {
+	err := ix.scanner.Scan(ctx, func(r *scanner.Range) error {
+		events, err := ix.fetchEvents(r)
+		if err != nil {
+			return err
+		}
+		// events and checkpoint are committed together so a sync can stop anywhere
+		err = ix.store.Commit(ix.source, events, &scanner.Checkpoint{BlockNumber: r.End, BlockHash: r.EndHash})
+		if err != nil {
+			return fmt.Errorf("Commit: %w", err)
+		}
+		log.Printf("indexed blocks %d-%d, %d events", r.Start, r.End, len(events))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if cp := ix.scanner.Checkpoint(); cp != nil {
+		return cp.BlockNumber, nil
+	}
+	return 0, nil
+}
+
+func (ix *Indexer) fetchEvents(r *scanner.Range) ([]*Event, error) {
+	var addrs []common.Address
+	for addr := range ix.contracts {
+		addrs = append(addrs, addr)
+	}
+	logs, err := ix.ec.FilterLogs(r.Context, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(r.Start),
+		ToBlock:   new(big.Int).SetUint64(r.End),
+		Addresses: addrs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("FilterLogs %d-%d: %w", r.Start, r.End, err)
+	}
+	var events []*Event
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		e, err := ix.contracts[l.Address].decode(l)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
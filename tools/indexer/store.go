@@ -0,0 +1,229 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package indexer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"tools/scanner"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// statements only use syntax shared by sqlite, postgres and cockroachdb
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS index_events (
+		tx_hash TEXT NOT NULL,
+		log_index INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		block_number BIGINT NOT NULL,
+		block_hash TEXT NOT NULL,
+		contract TEXT NOT NULL,
+		address TEXT NOT NULL,
+		name TEXT NOT NULL,
+		reqid TEXT NOT NULL,
+		prover TEXT NOT NULL,
+		account TEXT NOT NULL,
+		amount TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (tx_hash, log_index)
+	)`,
+	`CREATE INDEX IF NOT EXISTS index_events_block ON index_events (source, block_number)`,
+	`CREATE INDEX IF NOT EXISTS index_events_name ON index_events (name, block_number)`,
+	`CREATE INDEX IF NOT EXISTS index_events_reqid ON index_events (reqid)`,
+	`CREATE INDEX IF NOT EXISTS index_events_prover ON index_events (prover, block_number)`,
+	`CREATE INDEX IF NOT EXISTS index_events_account ON index_events (account, block_number)`,
+	`CREATE TABLE IF NOT EXISTS index_checkpoints (
+		id TEXT PRIMARY KEY,
+		block_number BIGINT NOT NULL,
+		block_hash TEXT NOT NULL
+	)`,
+}
+
+// Query filters indexed events, empty fields match everything
+type Query struct {
+	Source    string
+	Contract  string
+	Name      string
+	ReqId     string
+	Prover    string
+	Account   string
+	FromBlock uint64
+	ToBlock   uint64 // 0 means no upper bound
+	Limit     uint64 // 0 means no limit
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens the index database and creates the tables if needed. dbUrl
+// is either sqlite:<file> or a postgres:// / postgresql:// url, the latter
+// also works for cockroachdb
+func OpenStore(dbUrl string) (*Store, error) {
+	var driver, dsn string
+	switch {
+	case strings.HasPrefix(dbUrl, "sqlite:"):
+		driver, dsn = "sqlite", strings.TrimPrefix(strings.TrimPrefix(dbUrl, "sqlite:"), "//")
+	case strings.HasPrefix(dbUrl, "postgres://"), strings.HasPrefix(dbUrl, "postgresql://"):
+		driver, dsn = "postgres", dbUrl
+	default:
+		return nil, fmt.Errorf("unsupported db url %q, expect sqlite:<file> or postgresql://...", dbUrl)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		// sqlite allows a single writer
+		db.SetMaxOpenConns(1)
+	}
+	for _, stmt := range schema {
+		if _, err = db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("create schema: %w", err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Checkpoint returns the last block whose events are all in the index, nil if
+// nothing is indexed for source yet. Events and checkpoints are keyed by a
+// source id so one db can index several deployments
+func (s *Store) Checkpoint(source string) (*scanner.Checkpoint, error) {
+	var blk uint64
+	var hash string
+	err := s.db.QueryRow(`SELECT block_number, block_hash FROM index_checkpoints WHERE id = $1`, source).Scan(&blk, &hash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scanner.Checkpoint{BlockNumber: blk, BlockHash: common.HexToHash(hash)}, nil
+}
+
+// Commit saves events and moves the checkpoint in one transaction. Events
+// already stored are skipped so re-indexing a range is harmless
+func (s *Store) Commit(source string, events []*Event, cp *scanner.Checkpoint) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range events {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO index_events
+			(tx_hash, log_index, source, block_number, block_hash, contract, address, name, reqid, prover, account, amount, data)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (tx_hash, log_index) DO NOTHING`,
+			e.TxHash.Hex(), e.LogIndex, source, e.BlockNumber, e.BlockHash.Hex(), e.Contract, e.Address.Hex(), e.Name,
+			e.ReqId, e.Prover, e.Account, e.Amount, string(data))
+		if err != nil {
+			return fmt.Errorf("insert event: %w", err)
+		}
+	}
+	if err = setCheckpoint(tx, source, cp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rewind drops events after cp and moves the checkpoint back to it, used when
+// a reorg replaced indexed blocks
+func (s *Store) Rewind(source string, cp *scanner.Checkpoint) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`DELETE FROM index_events WHERE source = $1 AND block_number > $2`, source, cp.BlockNumber); err != nil {
+		return fmt.Errorf("delete events: %w", err)
+	}
+	if err = setCheckpoint(tx, source, cp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setCheckpoint(tx *sql.Tx, source string, cp *scanner.Checkpoint) error {
+	_, err := tx.Exec(`INSERT INTO index_checkpoints (id, block_number, block_hash) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET block_number = excluded.block_number, block_hash = excluded.block_hash`,
+		source, cp.BlockNumber, cp.BlockHash.Hex())
+	if err != nil {
+		return fmt.Errorf("set checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Events returns indexed events matching q in chain order
+func (s *Store) Events(q *Query) ([]*Event, error) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	add("block_number >= $%d", q.FromBlock)
+	if q.Source != "" {
+		add("source = $%d", q.Source)
+	}
+	if q.ToBlock != 0 {
+		add("block_number <= $%d", q.ToBlock)
+	}
+	if q.Contract != "" {
+		add("contract = $%d", q.Contract)
+	}
+	if q.Name != "" {
+		add("name = $%d", q.Name)
+	}
+	// hex columns are stored as produced by go-ethereum, match the same form
+	if q.ReqId != "" {
+		add("reqid = $%d", common.HexToHash(q.ReqId).Hex())
+	}
+	if q.Prover != "" {
+		add("prover = $%d", common.HexToAddress(q.Prover).Hex())
+	}
+	if q.Account != "" {
+		add("account = $%d", common.HexToAddress(q.Account).Hex())
+	}
+	stmt := `SELECT tx_hash, log_index, block_number, block_hash, contract, address, name, reqid, prover, account, amount, data
+		FROM index_events WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY block_number, log_index`
+	if q.Limit != 0 {
+		stmt += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		var txHash, blockHash, address, data string
+		e := &Event{}
+		err = rows.Scan(&txHash, &e.LogIndex, &e.BlockNumber, &blockHash, &e.Contract, &address, &e.Name,
+			&e.ReqId, &e.Prover, &e.Account, &e.Amount, &data)
+		if err != nil {
+			return nil, err
+		}
+		e.TxHash, e.BlockHash, e.Address = common.HexToHash(txHash), common.HexToHash(blockHash), common.HexToAddress(address)
+		if err = json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
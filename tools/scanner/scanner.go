@@ -0,0 +1,305 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is what the scanner needs from the chain, *ethclient.Client implements it
+type Backend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+type Config struct {
+	MaxBlkDelta   uint64        // initial and max block range per log query, default 5000
+	MinBlkDelta   uint64        // the range is not shrunk below this, default 1
+	Confirmations uint64        // only blocks this deep are scanned
+	ReorgDepth    uint64        // blocks to rewind when the last scanned block was reorged out, default 64
+	MaxRetries    int           // retries of a range the rpc rate limits or times out, default 5
+	RetryDelay    time.Duration // first delay between retries, doubled after each, default 1s
+}
+
+// Checkpoint is the last scanned block
+type Checkpoint struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+}
+
+// Range is a block range handed to the scan handler. EndHash is fetched before
+// the handler runs, so logs of a fork that replaces End after that are caught
+// by the reorg check of the next scan
+type Range struct {
+	Start   uint64
+	End     uint64
+	EndHash common.Hash
+	Context context.Context
+}
+
+func (r *Range) FilterOpts() *bind.FilterOpts {
+	end := r.End
+	return &bind.FilterOpts{Start: r.Start, End: &end, Context: r.Context}
+}
+
+// Scanner walks block ranges from a start block or checkpoint up to the
+// confirmed head. The range shrinks when the rpc rejects a log query as too
+// large and grows back after successful queries
+type Scanner struct {
+	backend Backend
+	cfg     Config
+	next    uint64
+	cp      *Checkpoint
+	delta   uint64
+	recent  []*Checkpoint // ends of recently scanned ranges, newest last
+
+	// OnReorg is called with the checkpoint the scanner rewinds to after the
+	// last scanned block was reorged out, anything derived from later blocks
+	// should be dropped. The rewind is aborted if it returns an error
+	OnReorg func(cp *Checkpoint) error
+}
+
+// NewScanner starts scanning at from, or after cp if it is not nil
+func NewScanner(backend Backend, from uint64, cp *Checkpoint, cfg Config) *Scanner {
+	if cfg.MaxBlkDelta == 0 {
+		cfg.MaxBlkDelta = 5000
+	}
+	if cfg.MinBlkDelta == 0 {
+		cfg.MinBlkDelta = 1
+	}
+	if cfg.MinBlkDelta > cfg.MaxBlkDelta {
+		cfg.MinBlkDelta = cfg.MaxBlkDelta
+	}
+	if cfg.ReorgDepth == 0 {
+		cfg.ReorgDepth = 64
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = time.Second
+	}
+	s := &Scanner{backend: backend, cfg: cfg, next: from, cp: cp, delta: cfg.MaxBlkDelta}
+	if cp != nil {
+		s.next = cp.BlockNumber + 1
+	}
+	return s
+}
+
+// Checkpoint returns the last scanned block, nil if nothing was scanned yet
+func (s *Scanner) Checkpoint() *Checkpoint {
+	return s.cp
+}
+
+// Scan calls handle on consecutive ranges up to the confirmed head. The
+// checkpoint only moves after handle succeeds. A range is retried with a
+// smaller size if handle fails with a too many results error, and with the
+// same size after a backoff if it is rate limited, so handle must tolerate
+// seeing a range again. The last scanned block is checked for a reorg before
+// each range
+func (s *Scanner) Scan(ctx context.Context, handle func(r *Range) error) error {
+	return s.ScanTo(ctx, math.MaxUint64, handle)
+}
+
+// ScanTo is Scan that stops at block to if it is before the confirmed head
+func (s *Scanner) ScanTo(ctx context.Context, to uint64, handle func(r *Range) error) error {
+	head, err := s.backend.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("BlockNumber: %w", err)
+	}
+	if head < s.cfg.Confirmations {
+		return nil
+	}
+	safe := min(head-s.cfg.Confirmations, to)
+
+	retries := 0
+	for {
+		if err = s.checkReorg(ctx); err != nil {
+			return err
+		}
+		if s.next > safe {
+			return nil
+		}
+		end := s.next + s.delta - 1
+		if end > safe {
+			end = safe
+		}
+		header, err := s.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(end))
+		if err != nil {
+			return fmt.Errorf("HeaderByNumber %d: %w", end, err)
+		}
+		r := &Range{Start: s.next, End: end, EndHash: header.Hash(), Context: ctx}
+		if err = handle(r); err != nil {
+			if IsRetryError(err) && retries < s.cfg.MaxRetries {
+				delay := s.cfg.RetryDelay << retries
+				retries++
+				log.Printf("blocks %d-%d failed, retry %d in %s: %s", r.Start, r.End, retries, delay, err)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(delay):
+				}
+				continue
+			}
+			if IsRangeError(err) && s.delta > s.cfg.MinBlkDelta {
+				s.delta = max(s.delta/2, s.cfg.MinBlkDelta)
+				log.Printf("blocks %d-%d rejected by rpc, retry with range %d: %s", r.Start, r.End, s.delta, err)
+				continue
+			}
+			return err
+		}
+		retries = 0
+		s.cp = &Checkpoint{BlockNumber: end, BlockHash: r.EndHash}
+		s.recent = append(s.recent, s.cp)
+		if len(s.recent) > maxRecent {
+			s.recent = s.recent[1:]
+		}
+		s.next = end + 1
+		if s.delta < s.cfg.MaxBlkDelta {
+			s.delta = min(s.delta*2, s.cfg.MaxBlkDelta)
+		}
+	}
+}
+
+// checkReorg rewinds to the newest recently scanned range end that is still
+// on the chain if the checkpoint block hash changed. If none is, eg. right
+// after a restart, it rewinds ReorgDepth blocks
+func (s *Scanner) checkReorg(ctx context.Context) error {
+	if s.cp == nil {
+		return nil
+	}
+	header, err := s.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(s.cp.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("HeaderByNumber %d: %w", s.cp.BlockNumber, err)
+	}
+	if header.Hash() == s.cp.BlockHash {
+		return nil
+	}
+	reorged := s.cp.BlockNumber
+	var cp *Checkpoint
+	for len(s.recent) > 0 && cp == nil {
+		last := s.recent[len(s.recent)-1]
+		s.recent = s.recent[:len(s.recent)-1]
+		if last.BlockNumber+s.cfg.ReorgDepth < reorged {
+			s.recent = nil
+			break
+		}
+		header, err = s.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(last.BlockNumber))
+		if err != nil {
+			return fmt.Errorf("HeaderByNumber %d: %w", last.BlockNumber, err)
+		}
+		if header.Hash() == last.BlockHash {
+			cp = last
+		}
+	}
+	if cp == nil {
+		var rewindTo uint64
+		if reorged > s.cfg.ReorgDepth {
+			rewindTo = reorged - s.cfg.ReorgDepth
+		}
+		header, err = s.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(rewindTo))
+		if err != nil {
+			return fmt.Errorf("HeaderByNumber %d: %w", rewindTo, err)
+		}
+		cp = &Checkpoint{BlockNumber: rewindTo, BlockHash: header.Hash()}
+	}
+	log.Printf("block %d was reorged out, rewinding scan to block %d", reorged, cp.BlockNumber)
+	if s.OnReorg != nil {
+		if err = s.OnReorg(cp); err != nil {
+			return fmt.Errorf("OnReorg: %w", err)
+		}
+	}
+	s.cp = cp
+	s.recent = append(s.recent, cp)
+	s.next = cp.BlockNumber + 1
+	return nil
+}
+
+// maxRecent is how many range ends are kept to find where a reorg started
+const maxRecent = 128
+
+// rangeErrors are lowercase fragments of errors rpc providers return when a
+// log query covers too many blocks or matches too many logs
+var rangeErrors = []string{
+	"query returned more than",
+	"too many results",
+	"too many blocks",
+	"block range",
+	"range is too large",
+	"range too large",
+	"range limit",
+	"response size",
+}
+
+// retryErrors are lowercase fragments of errors rpc providers return when a
+// query is rate limited or timed out, the same range may succeed later
+var retryErrors = []string{
+	"rate limit",
+	"too many requests",
+	"request rate exceeded",
+	"capacity exceeded",
+	"query timeout",
+	"request timeout",
+}
+
+// IsRangeError tells if err means the log query should cover fewer blocks
+func IsRangeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, frag := range rangeErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryError tells if err means the log query should be retried later
+func IsRetryError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, frag := range retryErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterator is implemented by the iterators generated Filter* methods return
+type Iterator interface {
+	Next() bool
+	Error() error
+	Close() error
+}
+
+// Each runs a generated Filter* query over r and calls fn for every event,
+// which fn reads from the iterator's Event field. For example
+//
+//	scanner.Each(r, func(opts *bind.FilterOpts) (*bindings.BrevisMarketRefundedIterator, error) {
+//		return brevisMarket.FilterRefunded(opts, nil, nil)
+//	}, func(it *bindings.BrevisMarketRefundedIterator) error {
+//		...it.Event...
+//	})
+func Each[I Iterator](r *Range, filter func(opts *bind.FilterOpts) (I, error), fn func(it I) error) error {
+	it, err := filter(r.FilterOpts())
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err = fn(it); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
@@ -0,0 +1,282 @@
+/*
+Copyright © 2025 Brevis Network
+*/
+package scanner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeBackend is a chain whose blocks from fork on have a different hash
+// after each reorg
+type fakeBackend struct {
+	head  uint64
+	fork  uint64
+	forks uint64
+}
+
+func (b *fakeBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.head, nil
+}
+
+func (b *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	h := &types.Header{Number: new(big.Int).Set(number)}
+	if b.forks > 0 && number.Uint64() >= b.fork {
+		h.Extra = []byte{byte(b.forks)}
+	}
+	return h, nil
+}
+
+// reorg replaces every block from fork on
+func (b *fakeBackend) reorg(fork uint64) {
+	b.fork = fork
+	b.forks++
+}
+
+// recorder is a scan handler that keeps the ranges it accepted
+type recorder struct {
+	ranges [][2]uint64
+}
+
+func (rec *recorder) handle(r *Range) error {
+	rec.ranges = append(rec.ranges, [2]uint64{r.Start, r.End})
+	return nil
+}
+
+// checkContiguous checks that ranges cover from..to without gaps or overlaps
+func checkContiguous(t *testing.T, ranges [][2]uint64, from, to uint64) {
+	t.Helper()
+	next := from
+	for _, r := range ranges {
+		if r[0] != next || r[1] < r[0] {
+			t.Fatalf("range %d-%d does not start at %d, ranges %v", r[0], r[1], next, ranges)
+		}
+		next = r[1] + 1
+	}
+	if next != to+1 {
+		t.Fatalf("ranges end at %d, want %d, ranges %v", next-1, to, ranges)
+	}
+}
+
+func TestScanHalvesRangeOnRangeError(t *testing.T) {
+	b := &fakeBackend{head: 999}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 1000})
+	var tried []uint64
+	rec := &recorder{}
+	err := s.Scan(context.Background(), func(r *Range) error {
+		size := r.End - r.Start + 1
+		tried = append(tried, size)
+		if size > 300 {
+			return errors.New("query returned more than 10000 results")
+		}
+		return rec.handle(r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tried[0] != 1000 || tried[1] != 500 || tried[2] != 250 {
+		t.Errorf("tried sizes %v, want 1000, 500, 250 first", tried)
+	}
+	checkContiguous(t, rec.ranges, 0, 999)
+	if cp := s.Checkpoint(); cp == nil || cp.BlockNumber != 999 {
+		t.Errorf("checkpoint %v, want block 999", cp)
+	}
+}
+
+func TestScanStopsHalvingAtMinBlkDelta(t *testing.T) {
+	b := &fakeBackend{head: 999}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 1000, MinBlkDelta: 200})
+	var tried []uint64
+	err := s.Scan(context.Background(), func(r *Range) error {
+		tried = append(tried, r.End-r.Start+1)
+		return errors.New("block range is too large")
+	})
+	if err == nil || !IsRangeError(err) {
+		t.Fatalf("err %v, want the range error", err)
+	}
+	want := []uint64{1000, 500, 250, 200}
+	if len(tried) != len(want) {
+		t.Fatalf("tried sizes %v, want %v", tried, want)
+	}
+	for i := range want {
+		if tried[i] != want[i] {
+			t.Fatalf("tried sizes %v, want %v", tried, want)
+		}
+	}
+	if s.Checkpoint() != nil {
+		t.Errorf("checkpoint moved to %v without a successful range", s.Checkpoint())
+	}
+}
+
+func TestScanRetriesRateLimit(t *testing.T) {
+	b := &fakeBackend{head: 99}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 100, RetryDelay: time.Millisecond})
+	fails := 2
+	rec := &recorder{}
+	err := s.Scan(context.Background(), func(r *Range) error {
+		if fails > 0 {
+			fails--
+			return errors.New("429 Too Many Requests: rate limit exceeded")
+		}
+		return rec.handle(r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rate limits retry the same range instead of halving it
+	if len(rec.ranges) != 1 || rec.ranges[0] != [2]uint64{0, 99} {
+		t.Errorf("ranges %v, want 0-99", rec.ranges)
+	}
+
+	s = NewScanner(b, 0, nil, Config{MaxBlkDelta: 100, MaxRetries: 3, RetryDelay: time.Millisecond})
+	tries := 0
+	err = s.Scan(context.Background(), func(r *Range) error {
+		tries++
+		return errors.New("query timeout exceeded")
+	})
+	if err == nil || tries != 4 {
+		t.Errorf("err %v after %d tries, want an error after 4", err, tries)
+	}
+}
+
+func TestScanConfirmations(t *testing.T) {
+	b := &fakeBackend{head: 100}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 30, Confirmations: 10})
+	rec := &recorder{}
+	if err := s.Scan(context.Background(), rec.handle); err != nil {
+		t.Fatal(err)
+	}
+	checkContiguous(t, rec.ranges, 0, 90)
+
+	b.head = 5
+	s = NewScanner(b, 0, nil, Config{Confirmations: 10})
+	rec = &recorder{}
+	if err := s.Scan(context.Background(), rec.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.ranges) != 0 {
+		t.Errorf("scanned %v before any block is confirmed", rec.ranges)
+	}
+}
+
+func TestScanRewindsToSurvivingCheckpoint(t *testing.T) {
+	b := &fakeBackend{head: 99}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 10})
+	var rewound []uint64
+	s.OnReorg = func(cp *Checkpoint) error {
+		rewound = append(rewound, cp.BlockNumber)
+		return nil
+	}
+	if err := s.Scan(context.Background(), (&recorder{}).handle); err != nil {
+		t.Fatal(err)
+	}
+
+	// range ends 79 and 89 are replaced, 69 is the newest one left
+	b.reorg(75)
+	b.head = 109
+	rec := &recorder{}
+	if err := s.Scan(context.Background(), rec.handle); err != nil {
+		t.Fatal(err)
+	}
+	if len(rewound) != 1 || rewound[0] != 69 {
+		t.Fatalf("OnReorg called with %v, want [69]", rewound)
+	}
+	checkContiguous(t, rec.ranges, 70, 109)
+}
+
+func TestScanRewindsReorgDepthWithoutCheckpoints(t *testing.T) {
+	b := &fakeBackend{head: 200}
+	stale, _ := b.HeaderByNumber(context.Background(), big.NewInt(150))
+	// the checkpoint was saved before a restart and then reorged out
+	b.reorg(140)
+	cp := &Checkpoint{BlockNumber: 150, BlockHash: stale.Hash()}
+	s := NewScanner(b, 0, cp, Config{MaxBlkDelta: 100, ReorgDepth: 20})
+	var rewound *Checkpoint
+	s.OnReorg = func(cp *Checkpoint) error {
+		rewound = cp
+		return nil
+	}
+	rec := &recorder{}
+	if err := s.Scan(context.Background(), rec.handle); err != nil {
+		t.Fatal(err)
+	}
+	header, _ := b.HeaderByNumber(context.Background(), big.NewInt(130))
+	if rewound == nil || rewound.BlockNumber != 130 || rewound.BlockHash != header.Hash() {
+		t.Fatalf("OnReorg called with %v, want block 130 of the new chain", rewound)
+	}
+	checkContiguous(t, rec.ranges, 131, 200)
+}
+
+func TestScanChecksReorgBetweenRanges(t *testing.T) {
+	b := &fakeBackend{head: 99}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 10})
+	var rewound []uint64
+	s.OnReorg = func(cp *Checkpoint) error {
+		rewound = append(rewound, cp.BlockNumber)
+		return nil
+	}
+	rec := &recorder{}
+	err := s.Scan(context.Background(), func(r *Range) error {
+		if r.Start == 50 && b.forks == 0 {
+			b.reorg(45)
+		}
+		return rec.handle(r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewound) != 1 || rewound[0] != 39 {
+		t.Fatalf("OnReorg called with %v, want [39]", rewound)
+	}
+	// 40-59 is scanned again right after the reorg, not on the next Scan
+	last := rec.ranges[len(rec.ranges)-1]
+	if last != [2]uint64{90, 99} {
+		t.Errorf("last range %v, want 90-99", last)
+	}
+	checkContiguous(t, rec.ranges[6:], 40, 99)
+}
+
+func TestScanOnReorgErrorAbortsRewind(t *testing.T) {
+	b := &fakeBackend{head: 99}
+	s := NewScanner(b, 0, nil, Config{MaxBlkDelta: 50})
+	if err := s.Scan(context.Background(), (&recorder{}).handle); err != nil {
+		t.Fatal(err)
+	}
+	b.reorg(60)
+	s.OnReorg = func(cp *Checkpoint) error {
+		return errors.New("db down")
+	}
+	if err := s.Scan(context.Background(), (&recorder{}).handle); err == nil {
+		t.Fatal("Scan succeeded although OnReorg failed")
+	}
+	if cp := s.Checkpoint(); cp.BlockNumber != 99 {
+		t.Errorf("checkpoint moved to %d, want it kept at 99", cp.BlockNumber)
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	cases := []struct {
+		msg              string
+		isRange, isRetry bool
+	}{
+		{"query returned more than 10000 results", true, false},
+		{"eth_getLogs is limited to a 10,000 block range", true, false},
+		{"Log response size exceeded", true, false},
+		{"rate limit exceeded", false, true},
+		{"429 Too Many Requests", false, true},
+		{"query timeout exceeded", false, true},
+		{"execution reverted", false, false},
+	}
+	for _, c := range cases {
+		err := errors.New(c.msg)
+		if IsRangeError(err) != c.isRange || IsRetryError(err) != c.isRetry {
+			t.Errorf("%q: range %v retry %v, want %v %v", c.msg, IsRangeError(err), IsRetryError(err), c.isRange, c.isRetry)
+		}
+	}
+}